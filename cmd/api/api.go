@@ -16,6 +16,7 @@ import (
 	"github.com/d4rthvadr/dusky-go/internal/http/handlers"
 	apphttpRouter "github.com/d4rthvadr/dusky-go/internal/http/router"
 	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/passwords"
 	ratelimiter "github.com/d4rthvadr/dusky-go/internal/ratelmiter"
 	"github.com/d4rthvadr/dusky-go/internal/store"
 	"github.com/d4rthvadr/dusky-go/internal/utils/logger"
@@ -31,8 +32,9 @@ type application struct {
 	db               *sql.DB
 	cache            cache.CacheStorage
 	logger           logger.Logger
-	jwtAuthenticator *auth.JWTAuthenticator
+	jwtAuthenticator auth.Authenticator
 	handler          *handlers.Handler
+	userConfig       config.UserConfig
 }
 
 type AppConfig struct {
@@ -51,13 +53,14 @@ func (app *application) mount() *chi.Mux {
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
+	apphttpRouter.MountWellKnownRoutes(r, app.handler)
 	apphttpRouter.MountV1Routes(r, app.handler, app.config.apiUrl)
 
 	return r
@@ -96,6 +99,12 @@ func (app *application) Run(mux *chi.Mux) error {
 
 	shutdown := make(chan error, 1)
 
+	// Background jobs stop when the server returns
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	go app.runUnactivatedUserCleanup(jobsCtx)
+
 	// Graceful shutdown
 	go func() {
 		quit := make(chan os.Signal, 1)
@@ -113,6 +122,9 @@ func (app *application) Run(mux *chi.Mux) error {
 			return
 		}
 
+		// emails queued by requests that already got their response are still sent
+		app.handler.Wait()
+
 		shutdown <- nil
 
 	}()
@@ -135,16 +147,27 @@ func (app *application) Run(mux *chi.Mux) error {
 }
 
 type appOptions struct {
-	config           AppConfig
-	store            store.Storage
-	db               *sql.DB
-	cache            cache.CacheStorage
-	logger           logger.Logger
-	mailConfig       config.MailConfig
-	mailer           mailer.Client
-	jwtAuthenticator *auth.JWTAuthenticator
-	rateLimiter      ratelimiter.Limiter
-	isProdEnv        bool
+	config               AppConfig
+	store                store.Storage
+	db                   *sql.DB
+	cache                cache.CacheStorage
+	logger               logger.Logger
+	mailConfig           config.MailConfig
+	mailer               mailer.Client
+	jwtAuthenticator     auth.Authenticator
+	totpSecretBox        *auth.SecretBox
+	rateLimiter          ratelimiter.Limiter
+	postConfig           config.PostConfig
+	jwtConfig            config.JWTConfig
+	userConfig           config.UserConfig
+	loginConfig          config.LoginConfig
+	loginLimiter         ratelimiter.Limiter
+	magicLinkLimiter     ratelimiter.Limiter
+	passwordResetLimiter ratelimiter.Limiter
+	activationLimiter    ratelimiter.Limiter
+	roleConfig           config.RoleConfig
+	passwordPolicy       *passwords.Policy
+	isProdEnv            bool
 }
 
 func NewApplication(options appOptions) *application {
@@ -155,16 +178,27 @@ func NewApplication(options appOptions) *application {
 		cache:            options.cache,
 		logger:           options.logger,
 		jwtAuthenticator: options.jwtAuthenticator,
+		userConfig:       options.userConfig,
 		handler: handlers.New(handlers.HandlerOptions{
-			Store:            options.store,
-			Version:          version,
-			Logger:           options.logger,
-			MailConfig:       options.mailConfig,
-			Mailer:           options.mailer,
-			JWTAuthenticator: options.jwtAuthenticator,
-			Cache:            options.cache,
-			IsProdEnv:        options.isProdEnv,
-			RateLimiter:      options.rateLimiter,
+			Store:                options.store,
+			Version:              version,
+			Logger:               options.logger,
+			MailConfig:           options.mailConfig,
+			Mailer:               options.mailer,
+			JWTAuthenticator:     options.jwtAuthenticator,
+			TOTPSecretBox:        options.totpSecretBox,
+			Cache:                options.cache,
+			IsProdEnv:            options.isProdEnv,
+			RateLimiter:          options.rateLimiter,
+			PostConfig:           options.postConfig,
+			JWTConfig:            options.jwtConfig,
+			LoginConfig:          options.loginConfig,
+			LoginLimiter:         options.loginLimiter,
+			MagicLinkLimiter:     options.magicLinkLimiter,
+			PasswordResetLimiter: options.passwordResetLimiter,
+			ActivationLimiter:    options.activationLimiter,
+			RoleConfig:           options.roleConfig,
+			PasswordPolicy:       options.passwordPolicy,
 		}),
 	}
 }
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// recordingLoginAttemptStore reports the configured recent failures for every account and keeps the attempts
+// that were recorded.
+type recordingLoginAttemptStore struct {
+	*store.LoginAttemptStoreMock
+	failures models.LoginFailures
+	recorded []models.LoginAttempt
+}
+
+func (s *recordingLoginAttemptStore) Begin(context.Context, *models.LoginAttempt, time.Time) (*models.LoginFailures, error) {
+	failures := s.failures
+	return &failures, nil
+}
+
+func (s *recordingLoginAttemptStore) Record(_ context.Context, attempt *models.LoginAttempt) error {
+	s.recorded = append(s.recorded, *attempt)
+	return nil
+}
+
+func newLoginAttemptTestApplication(t *testing.T, loginAttempts *recordingLoginAttemptStore) *application {
+	t.Helper()
+
+	mockStore := store.NewMockStore()
+	mockStore.LoginAttempts = loginAttempts
+
+	return newTestApplicationWith(t, mockStore, &mailer.MockMailer{})
+}
+
+func TestAuth(t *testing.T) {
+
+	t.Run("should reject a refresh request without a refresh token", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/refresh", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should not allow unauthenticated users to log out", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/logout", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should revoke the access token on logout", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/logout", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusNoContent, response.Code)
+	})
+
+	t.Run("should publish the JWKS without authentication", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("should accept a password reset request without revealing the account", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"email": "someone@example.com"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/password/forgot", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+		app.handler.Wait()
+
+		// Assert
+		checkResponseCode(t, http.StatusAccepted, response.Code)
+	})
+
+	t.Run("should reject a password reset with mismatched passwords", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"token": "abc", "password": "new-password", "confirm_password": "other-password"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/password/reset", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should accept an activation resend request without revealing the account", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"email": "someone@example.com"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/activation/resend", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusAccepted, response.Code)
+	})
+
+	t.Run("should log in with the correct password", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(fmt.Sprintf(`{"email": "someone@example.com", "password": %q}`, store.MockUserPassword))
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/token", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("should reject a login with the wrong password", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"email": "someone@example.com", "password": "wrong-password"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/token", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should not accept an access token to complete a two-factor login", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(fmt.Sprintf(`{"mfa_token": %q, "code": "123456"}`, token))
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/mfa/login", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should count a wrong code when disabling two-factor authentication", func(t *testing.T) {
+
+		// Arrange
+		loginAttempts := &recordingLoginAttemptStore{LoginAttemptStoreMock: &store.LoginAttemptStoreMock{}}
+		app := newLoginAttemptTestApplication(t, loginAttempts)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("DELETE", "/v1/auth/mfa/totp", strings.NewReader(`{"code": "123456"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		if len(loginAttempts.recorded) != 1 || loginAttempts.recorded[0].FailureReason != models.LoginFailureInvalidCode {
+			t.Errorf("expected one %s attempt to be recorded, got %+v", models.LoginFailureInvalidCode, loginAttempts.recorded)
+		}
+	})
+
+	t.Run("should lock out disabling two-factor authentication after too many wrong codes", func(t *testing.T) {
+
+		// Arrange
+		loginAttempts := &recordingLoginAttemptStore{
+			LoginAttemptStoreMock: &store.LoginAttemptStoreMock{},
+			failures:              models.LoginFailures{Count: 5, LastFailedAt: time.Now()},
+		}
+		app := newLoginAttemptTestApplication(t, loginAttempts)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("DELETE", "/v1/auth/mfa/totp", strings.NewReader(`{"code": "123456"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusTooManyRequests, response.Code)
+		if response.Header().Get("Retry-After") == "" {
+			t.Error("expected a Retry-After header")
+		}
+		if len(loginAttempts.recorded) != 1 || loginAttempts.recorded[0].FailureReason != models.LoginFailureLockedOut {
+			t.Errorf("expected one %s attempt to be recorded, got %+v", models.LoginFailureLockedOut, loginAttempts.recorded)
+		}
+	})
+
+	t.Run("should accept a login link request without revealing the account", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"email": "someone@example.com"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/magic-link", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusAccepted, response.Code)
+	})
+
+	t.Run("should reject a login link exchange without a token", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/auth/magic-link/consume", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should list the sessions of the authenticated user", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/auth/sessions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("should reject an access token without a session", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		now := time.Now()
+		token, err := app.jwtAuthenticator.GenerateToken(jwt.MapClaims{
+			"sub": 1,
+			"aud": app.jwtAuthenticator.Audience(),
+			"iss": app.jwtAuthenticator.Issuer(),
+			"jti": uuid.New().String(),
+			"iat": now.Unix(),
+			"exp": now.Add(app.jwtAuthenticator.TokenExpiry()).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/auth/sessions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should reject revoking a session with an invalid ID", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("DELETE", "/v1/auth/sessions/not-a-session", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+// bookmarkStore keeps the bookmarked post IDs of each user in memory.
+type bookmarkStore struct {
+	*store.BookmarkStoreMock
+	bookmarks  map[int64]map[int64]bool
+	listUserID int64
+}
+
+func (s *bookmarkStore) Add(_ context.Context, userID, postID int64) error {
+	if s.bookmarks[userID] == nil {
+		s.bookmarks[userID] = make(map[int64]bool)
+	}
+	s.bookmarks[userID][postID] = true
+	return nil
+}
+
+func (s *bookmarkStore) Remove(_ context.Context, userID, postID int64) error {
+	delete(s.bookmarks[userID], postID)
+	return nil
+}
+
+func (s *bookmarkStore) GetUserBookmarks(_ context.Context, userID int64, _ *store.FeedQuery) (*store.FeedPage, error) {
+	s.listUserID = userID
+	return &store.FeedPage{}, nil
+}
+
+// missingPostStore finds no post.
+type missingPostStore struct {
+	*store.PostStoreMock
+}
+
+func (s *missingPostStore) GetByID(context.Context, int64) (*models.Post, error) {
+	return nil, errCustom.ErrResourceNotFound
+}
+
+func newBookmarkTestApplication(t *testing.T, bookmarks *bookmarkStore) *application {
+	t.Helper()
+
+	mockStore := store.NewMockStore()
+	mockStore.Bookmarks = bookmarks
+
+	return newTestApplicationWith(t, mockStore, &mailer.MockMailer{})
+}
+
+// executeBookmarkRequest runs a request on the bookmarks API as user 1.
+func executeBookmarkRequest(t *testing.T, app *application, method, endpoint string) int {
+	t.Helper()
+
+	request, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := generateTokenForUser(1, app.jwtAuthenticator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return executeRequest(app.mount(), request).Code
+}
+
+func TestBookmarks(t *testing.T) {
+
+	t.Run("should bookmark a post", func(t *testing.T) {
+
+		// Arrange
+		bookmarks := &bookmarkStore{bookmarks: map[int64]map[int64]bool{}}
+		app := newBookmarkTestApplication(t, bookmarks)
+
+		// Act
+		code := executeBookmarkRequest(t, app, "PUT", "/v1/posts/3/bookmark")
+
+		// Assert
+		checkResponseCode(t, http.StatusNoContent, code)
+		if !bookmarks.bookmarks[1][3] {
+			t.Errorf("expected post 3 to be bookmarked by user 1")
+		}
+	})
+
+	t.Run("should accept bookmarking a post again", func(t *testing.T) {
+
+		// Arrange
+		bookmarks := &bookmarkStore{bookmarks: map[int64]map[int64]bool{1: {3: true}}}
+		app := newBookmarkTestApplication(t, bookmarks)
+
+		// Act
+		code := executeBookmarkRequest(t, app, "PUT", "/v1/posts/3/bookmark")
+
+		// Assert
+		checkResponseCode(t, http.StatusNoContent, code)
+		if len(bookmarks.bookmarks[1]) != 1 || !bookmarks.bookmarks[1][3] {
+			t.Errorf("expected post 3 to stay bookmarked once, got %v", bookmarks.bookmarks[1])
+		}
+	})
+
+	t.Run("should remove a bookmark", func(t *testing.T) {
+
+		// Arrange
+		bookmarks := &bookmarkStore{bookmarks: map[int64]map[int64]bool{1: {3: true}}}
+		app := newBookmarkTestApplication(t, bookmarks)
+
+		// Act
+		code := executeBookmarkRequest(t, app, "DELETE", "/v1/posts/3/bookmark")
+
+		// Assert
+		checkResponseCode(t, http.StatusNoContent, code)
+		if bookmarks.bookmarks[1][3] {
+			t.Errorf("expected the bookmark of post 3 to be removed")
+		}
+	})
+
+	t.Run("should list the bookmarks of the authenticated user", func(t *testing.T) {
+
+		// Arrange
+		bookmarks := &bookmarkStore{bookmarks: map[int64]map[int64]bool{}}
+		app := newBookmarkTestApplication(t, bookmarks)
+
+		// Act
+		code := executeBookmarkRequest(t, app, "GET", "/v1/users/me/bookmarks")
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, code)
+		if bookmarks.listUserID != 1 {
+			t.Errorf("expected the bookmarks of user 1, got user %d", bookmarks.listUserID)
+		}
+	})
+
+	t.Run("should not bookmark an unknown post", func(t *testing.T) {
+
+		// Arrange
+		bookmarks := &bookmarkStore{bookmarks: map[int64]map[int64]bool{}}
+		mockStore := store.NewMockStore()
+		mockStore.Posts = &missingPostStore{}
+		mockStore.Bookmarks = bookmarks
+		app := newTestApplicationWith(t, mockStore, &mailer.MockMailer{})
+
+		// Act
+		code := executeBookmarkRequest(t, app, "PUT", "/v1/posts/404/bookmark")
+
+		// Assert
+		checkResponseCode(t, http.StatusNotFound, code)
+		if len(bookmarks.bookmarks) != 0 {
+			t.Errorf("expected no bookmark, got %v", bookmarks.bookmarks)
+		}
+	})
+
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+// replyCommentStore finds the configured parent for any comment ID and records whether a reply was created.
+type replyCommentStore struct {
+	*store.CommentStoreMock
+	parent  models.Comment
+	created bool
+}
+
+func (s *replyCommentStore) GetByID(context.Context, int64) (*models.Comment, error) {
+	parent := s.parent
+	return &parent, nil
+}
+
+func (s *replyCommentStore) Create(context.Context, *models.Comment) error {
+	s.created = true
+	return nil
+}
+
+// newCommentRequest builds a request on the comments API authenticated as the given user.
+func newCommentRequest(t *testing.T, app *application, userID int64, method, endpoint, body string) *http.Request {
+	t.Helper()
+
+	request, err := http.NewRequest(method, endpoint, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := generateTokenForUser(userID, app.jwtAuthenticator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return request
+}
+
+// replyToComment posts a reply to comment 1 on the given post as user 1.
+func replyToComment(t *testing.T, app *application, postID int64) int {
+	t.Helper()
+
+	endpoint := fmt.Sprintf("/v1/posts/%d/comments", postID)
+	request := newCommentRequest(t, app, 1, "POST", endpoint, `{"content": "hello", "parent_id": 1}`)
+
+	return executeRequest(app.mount(), request).Code
+}
+
+func newReplyTestApplication(t *testing.T, comments *replyCommentStore) *application {
+	t.Helper()
+
+	mockStore := store.NewMockStore()
+	mockStore.Comments = comments
+
+	return newTestApplicationWith(t, mockStore, &mailer.MockMailer{})
+}
+
+func TestComments(t *testing.T) {
+
+	t.Run("should not allow unauthenticated users to comment on a post", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"content": "hello"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/posts/1/comments", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should not allow unauthenticated users to delete a comment", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("DELETE", "/v1/posts/1/comments/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should reply to a comment below the maximum depth", func(t *testing.T) {
+
+		// Arrange
+		comments := &replyCommentStore{parent: models.Comment{ID: 1, PostID: 1, Depth: store.MaxCommentDepth - 1}}
+		app := newReplyTestApplication(t, comments)
+
+		// Act
+		code := replyToComment(t, app, 1)
+
+		// Assert
+		checkResponseCode(t, http.StatusCreated, code)
+		if !comments.created {
+			t.Errorf("expected the reply to be created")
+		}
+	})
+
+	t.Run("should not reply to a comment at the maximum depth", func(t *testing.T) {
+
+		// Arrange
+		comments := &replyCommentStore{parent: models.Comment{ID: 1, PostID: 1, Depth: store.MaxCommentDepth}}
+		app := newReplyTestApplication(t, comments)
+
+		// Act
+		code := replyToComment(t, app, 1)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, code)
+		if comments.created {
+			t.Errorf("expected no reply to be created")
+		}
+	})
+
+	t.Run("should not reply to a comment on another post", func(t *testing.T) {
+
+		// Arrange
+		comments := &replyCommentStore{parent: models.Comment{ID: 1, PostID: 2}}
+		app := newReplyTestApplication(t, comments)
+
+		// Act
+		code := replyToComment(t, app, 1)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, code)
+		if comments.created {
+			t.Errorf("expected no reply to be created")
+		}
+	})
+
+	t.Run("should not reply to a deleted comment", func(t *testing.T) {
+
+		// Arrange
+		comments := &replyCommentStore{parent: models.Comment{ID: 1, PostID: 1, IsDeleted: true}}
+		app := newReplyTestApplication(t, comments)
+
+		// Act
+		code := replyToComment(t, app, 1)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, code)
+		if comments.created {
+			t.Errorf("expected no reply to be created")
+		}
+	})
+
+	t.Run("should create a comment on a post", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request := newCommentRequest(t, app, 1, "POST", "/v1/posts/1/comments", `{"content": "hello"}`)
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		if !strings.Contains(response.Body.String(), `"content":"hello"`) {
+			t.Errorf("expected the comment in the response, got %s", response.Body.String())
+		}
+	})
+
+	t.Run("should list the comments of a post", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request := newCommentRequest(t, app, 1, "GET", "/v1/posts/1/comments", "")
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("should let the author update their comment", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request := newCommentRequest(t, app, 1, "PATCH", "/v1/posts/1/comments/1", `{"content": "edited"}`)
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+		if !strings.Contains(response.Body.String(), `"content":"edited"`) {
+			t.Errorf("expected the updated comment in the response, got %s", response.Body.String())
+		}
+	})
+
+	t.Run("should let the author delete their comment", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request := newCommentRequest(t, app, 1, "DELETE", "/v1/posts/1/comments/1", "")
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusNoContent, response.Code)
+	})
+
+	t.Run("should not allow other users to change a comment without permission", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		update := newCommentRequest(t, app, 2, "PATCH", "/v1/posts/1/comments/1", `{"content": "edited"}`)
+		updateResponse := executeRequest(mux, update)
+
+		remove := newCommentRequest(t, app, 2, "DELETE", "/v1/posts/1/comments/1", "")
+		removeResponse := executeRequest(mux, remove)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, updateResponse.Code)
+		checkResponseCode(t, http.StatusForbidden, removeResponse.Code)
+	})
+
+}
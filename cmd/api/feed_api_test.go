@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+// feedPostStore records whose feed and timeline were requested.
+type feedPostStore struct {
+	*store.PostStoreMock
+	feedUserID     int64
+	timelineUserID int64
+}
+
+func (s *feedPostStore) GetUserFeed(_ context.Context, userID int64, _ *store.FeedQuery) (*store.FeedPage, error) {
+	s.feedUserID = userID
+	return &store.FeedPage{}, nil
+}
+
+func (s *feedPostStore) GetUserPosts(_ context.Context, authorID int64, _ *store.FeedQuery) (*store.FeedPage, error) {
+	s.timelineUserID = authorID
+	return &store.FeedPage{}, nil
+}
+
+func newFeedTestApplication(t *testing.T, posts *feedPostStore) *application {
+	t.Helper()
+
+	mockStore := store.NewMockStore()
+	mockStore.Posts = posts
+
+	return newTestApplicationWith(t, mockStore, &mailer.MockMailer{})
+}
+
+func TestFeeds(t *testing.T) {
+
+	t.Run("should serve the explore feed without authentication", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/explore", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("should not serve the feed to unauthenticated users", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/users/feed", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should not serve a timeline to unauthenticated users", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/users/5/posts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should serve the feed of the authenticated user", func(t *testing.T) {
+
+		// Arrange
+		posts := &feedPostStore{}
+		app := newFeedTestApplication(t, posts)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(7, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/users/feed", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+		if posts.feedUserID != 7 {
+			t.Errorf("expected the feed of user 7, got user %d", posts.feedUserID)
+		}
+	})
+
+	t.Run("should serve the timeline of the user in the route", func(t *testing.T) {
+
+		// Arrange
+		posts := &feedPostStore{}
+		app := newFeedTestApplication(t, posts)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(7, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/users/5/posts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, response.Code)
+		if posts.timelineUserID != 5 {
+			t.Errorf("expected the timeline of user 5, got user %d", posts.timelineUserID)
+		}
+	})
+
+}
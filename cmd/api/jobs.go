@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runUnactivatedUserCleanup deletes accounts that were never activated once their last invitation has been expired
+// for longer than the configured retention, so their username and email can be registered again. It runs every
+// cleanup interval until ctx is cancelled; a non-positive interval disables it.
+func (app *application) runUnactivatedUserCleanup(ctx context.Context) {
+	interval := app.userConfig.CleanupInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := time.Now().Add(-app.userConfig.UnactivatedRetention)
+
+			deleted, err := app.store.Users.DeleteUnactivated(ctx, before)
+			if err != nil {
+				app.logger.Errorf("error deleting unactivated users: %v", err)
+				continue
+			}
+
+			if deleted > 0 {
+				app.logger.Infof("deleted %d unactivated users", deleted)
+			}
+		}
+	}
+}
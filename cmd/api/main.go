@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"expvar"
+	"fmt"
 	"log"
+	"math"
 	"runtime"
 
 	"github.com/d4rthvadr/dusky-go/internal/auth"
@@ -11,6 +15,7 @@ import (
 	"github.com/d4rthvadr/dusky-go/internal/config"
 	"github.com/d4rthvadr/dusky-go/internal/db"
 	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/passwords"
 	ratelimiter "github.com/d4rthvadr/dusky-go/internal/ratelmiter"
 	"github.com/d4rthvadr/dusky-go/internal/store"
 	"github.com/d4rthvadr/dusky-go/internal/utils/logger"
@@ -85,6 +90,41 @@ func main() {
 		rateLimiter = ratelimiter.NewFixedWindowRateLimiter(config.RateLimiter.RequestsPerTimeFrame, config.RateLimiter.TimeFrame)
 	}
 
+	// Login attempts are limited per client IP on top of the per-account lockout
+	var loginLimiter ratelimiter.Limiter
+	if config.Login.IPMaxAttempts > 0 {
+		loginLimiter = ratelimiter.NewFixedWindowRateLimiter(config.Login.IPMaxAttempts, config.Login.IPWindow)
+	}
+
+	// Login links are limited per email address so the endpoint cannot be used to flood an inbox
+	var magicLinkLimiter ratelimiter.Limiter
+	if config.Login.MagicLinkMaxSends > 0 {
+		magicLinkLimiter = ratelimiter.NewFixedWindowRateLimiter(config.Login.MagicLinkMaxSends, config.Login.MagicLinkWindow)
+	}
+
+	// Password reset links are limited the same way
+	var passwordResetLimiter ratelimiter.Limiter
+	if config.Login.PasswordResetMaxSends > 0 {
+		passwordResetLimiter = ratelimiter.NewFixedWindowRateLimiter(config.Login.PasswordResetMaxSends, config.Login.PasswordResetWindow)
+	}
+
+	// And so are resent activation links
+	var activationLimiter ratelimiter.Limiter
+	if config.Login.ActivationMaxSends > 0 {
+		activationLimiter = ratelimiter.NewFixedWindowRateLimiter(config.Login.ActivationMaxSends, config.Login.ActivationWindow)
+	}
+
+	passwordManager, err := newPasswordManager(config.Password)
+	if err != nil {
+		logger.Fatal("Error initializing password hashing:", err)
+	}
+	passwords.SetDefault(passwordManager)
+
+	passwordPolicy, err := passwords.NewPolicy(config.Password.MinLength, config.Password.BreachListFile)
+	if err != nil {
+		logger.Fatal("Error initializing password policy:", err)
+	}
+
 	appConfig := AppConfig{
 		addr:   config.Server.Host,
 		apiUrl: config.ApiUrl,
@@ -101,19 +141,38 @@ func main() {
 		logger.Fatal("Error initializing mailer:", err)
 	}
 
-	jwtAuthenticator := auth.NewJWTAuthenticator(config.JWT.SecretKey, config.JWT.Audience, config.JWT.Issuer, int64(config.JWT.Expiry))
+	jwtAuthenticator, err := newJWTAuthenticator(config.JWT)
+	if err != nil {
+		logger.Fatal("Error initializing JWT authenticator:", err)
+	}
+
+	totpSecretBox, err := newTOTPSecretBox(config.MFA)
+	if err != nil {
+		logger.Fatal("Error initializing TOTP secret encryption:", err)
+	}
 
 	app := NewApplication(appOptions{
-		config:           appConfig,
-		store:            store,
-		db:               db,
-		cache:            cacheStorage,
-		logger:           logger,
-		mailConfig:       mailConfig,
-		mailer:           mailer,
-		jwtAuthenticator: jwtAuthenticator,
-		rateLimiter:      rateLimiter,
-		isProdEnv:        isProdEnv,
+		config:               appConfig,
+		store:                store,
+		db:                   db,
+		cache:                cacheStorage,
+		logger:               logger,
+		mailConfig:           mailConfig,
+		mailer:               mailer,
+		jwtAuthenticator:     jwtAuthenticator,
+		totpSecretBox:        totpSecretBox,
+		rateLimiter:          rateLimiter,
+		postConfig:           config.Post,
+		jwtConfig:            config.JWT,
+		userConfig:           config.User,
+		loginConfig:          config.Login,
+		loginLimiter:         loginLimiter,
+		magicLinkLimiter:     magicLinkLimiter,
+		passwordResetLimiter: passwordResetLimiter,
+		activationLimiter:    activationLimiter,
+		roleConfig:           config.Role,
+		passwordPolicy:       passwordPolicy,
+		isProdEnv:            isProdEnv,
 	})
 
 	// Metrics collection
@@ -133,3 +192,66 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// newJWTAuthenticator signs with the configured PEM keys when there are any and falls back to the HS256 secret.
+// The secret keeps verifying older HS256 tokens while moving to asymmetric keys.
+func newJWTAuthenticator(jwtConfig config.JWTConfig) (*auth.JWTAuthenticator, error) {
+	if len(jwtConfig.KeyFiles) == 0 {
+		return auth.NewJWTAuthenticator(jwtConfig.SecretKey, jwtConfig.Audience, jwtConfig.Issuer, jwtConfig.Expiry), nil
+	}
+
+	keys := []*auth.SigningKey{}
+	for kid, path := range jwtConfig.KeyFiles {
+		key, err := auth.LoadPEMKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if jwtConfig.SecretKey != "" {
+		keys = append(keys, auth.NewHMACKey(auth.LegacyKeyID, jwtConfig.SecretKey))
+	}
+
+	keySet, err := auth.NewKeySet(jwtConfig.SigningKeyID, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewJWTAuthenticatorWithKeys(keySet, jwtConfig.Audience, jwtConfig.Issuer, jwtConfig.Expiry), nil
+}
+
+// newTOTPSecretBox builds the cipher TOTP secrets are stored with. The key is required, so secrets are never
+// stored in plaintext.
+func newTOTPSecretBox(mfaConfig config.MFAConfig) (*auth.SecretBox, error) {
+	if mfaConfig.TOTPEncryptionKey == "" {
+		return nil, errors.New("MFA_TOTP_ENCRYPTION_KEY is not set")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(mfaConfig.TOTPEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("MFA_TOTP_ENCRYPTION_KEY is not valid base64: %w", err)
+	}
+
+	return auth.NewSecretBox(key)
+}
+
+// newPasswordManager hashes new passwords with the configured algorithm and keeps verifying hashes of the other.
+func newPasswordManager(passwordConfig config.PasswordConfig) (*passwords.Manager, error) {
+	// Check the ranges before narrowing, so an out of range value fails instead of wrapping around.
+	switch {
+	case passwordConfig.Argon2Memory < 1 || int64(passwordConfig.Argon2Memory) > math.MaxUint32:
+		return nil, fmt.Errorf("PASSWORD_ARGON2_MEMORY must be between 1 and %d KiB", uint32(math.MaxUint32))
+	case passwordConfig.Argon2Iterations < 1 || int64(passwordConfig.Argon2Iterations) > math.MaxUint32:
+		return nil, fmt.Errorf("PASSWORD_ARGON2_ITERATIONS must be between 1 and %d", uint32(math.MaxUint32))
+	case passwordConfig.Argon2Parallelism < 1 || passwordConfig.Argon2Parallelism > math.MaxUint8:
+		return nil, fmt.Errorf("PASSWORD_ARGON2_PARALLELISM must be between 1 and %d", math.MaxUint8)
+	}
+
+	argon2idParams := passwords.DefaultArgon2idParams
+	argon2idParams.Memory = uint32(passwordConfig.Argon2Memory)
+	argon2idParams.Iterations = uint32(passwordConfig.Argon2Iterations)
+	argon2idParams.Parallelism = uint8(passwordConfig.Argon2Parallelism)
+
+	return passwords.NewManagerFor(passwordConfig.HashAlgorithm, argon2idParams, passwordConfig.BcryptCost)
+}
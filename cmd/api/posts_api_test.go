@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+// deletedPostStore holds a single post of user 1 that was soft-deleted at deletedAt.
+type deletedPostStore struct {
+	*store.PostStoreMock
+	deletedAt time.Time
+	restored  bool
+	purged    bool
+}
+
+func (s *deletedPostStore) GetByID(context.Context, int64) (*models.Post, error) {
+	return nil, errCustom.ErrResourceNotFound
+}
+
+func (s *deletedPostStore) GetDeletedByID(_ context.Context, postID int64) (*models.Post, error) {
+	return &models.Post{ID: postID, UserID: 1, Title: "mock", Content: "mock", Version: 1}, nil
+}
+
+func (s *deletedPostStore) Restore(_ context.Context, _ int64, deletedSince time.Time) error {
+	if s.deletedAt.Before(deletedSince) {
+		return errCustom.ErrResourceNotFound
+	}
+	s.restored = true
+	return nil
+}
+
+func (s *deletedPostStore) Purge(context.Context, int64) error {
+	s.purged = true
+	return nil
+}
+
+func newDeletedPostTestApplication(t *testing.T, posts *deletedPostStore) *application {
+	t.Helper()
+
+	mockStore := store.NewMockStore()
+	mockStore.Posts = posts
+
+	return newTestApplicationWith(t, mockStore, &mailer.MockMailer{})
+}
+
+// executePostRequest runs a request on the posts API as user 1.
+func executePostRequest(t *testing.T, app *application, method, endpoint string) int {
+	t.Helper()
+
+	request, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := generateTokenForUser(1, app.jwtAuthenticator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return executeRequest(app.mount(), request).Code
+}
+
+func TestDeletedPosts(t *testing.T) {
+
+	t.Run("should restore a post within the grace period", func(t *testing.T) {
+
+		// Arrange
+		posts := &deletedPostStore{deletedAt: time.Now().Add(-time.Hour)}
+		app := newDeletedPostTestApplication(t, posts)
+
+		// Act
+		code := executePostRequest(t, app, "POST", "/v1/posts/1/restore")
+
+		// Assert
+		checkResponseCode(t, http.StatusOK, code)
+		if !posts.restored {
+			t.Errorf("expected the post to be restored")
+		}
+	})
+
+	t.Run("should not restore a post after the grace period", func(t *testing.T) {
+
+		// Arrange
+		posts := &deletedPostStore{deletedAt: time.Now().Add(-time.Hour * 24 * 8)}
+		app := newDeletedPostTestApplication(t, posts)
+
+		// Act
+		code := executePostRequest(t, app, "POST", "/v1/posts/1/restore")
+
+		// Assert
+		checkResponseCode(t, http.StatusGone, code)
+		if posts.restored {
+			t.Errorf("expected the post to stay deleted")
+		}
+	})
+
+	t.Run("should not purge a post without the purge permission", func(t *testing.T) {
+
+		// Arrange
+		posts := &deletedPostStore{deletedAt: time.Now().Add(-time.Hour)}
+		app := newDeletedPostTestApplication(t, posts)
+
+		// Act
+		code := executePostRequest(t, app, "DELETE", "/v1/posts/1/purge")
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, code)
+		if posts.purged {
+			t.Errorf("expected the post not to be purged")
+		}
+	})
+
+	t.Run("should hide the comments and reactions of a deleted post", func(t *testing.T) {
+
+		// Arrange
+		posts := &deletedPostStore{deletedAt: time.Now().Add(-time.Hour)}
+		app := newDeletedPostTestApplication(t, posts)
+
+		requests := []struct {
+			method   string
+			endpoint string
+		}{
+			{"GET", "/v1/posts/1/comments"},
+			{"PUT", "/v1/posts/1/reactions/like"},
+			{"DELETE", "/v1/posts/1/reactions/like"},
+			{"PUT", "/v1/posts/1/bookmark"},
+			{"DELETE", "/v1/posts/1/bookmark"},
+		}
+
+		for _, request := range requests {
+
+			// Act
+			code := executePostRequest(t, app, request.method, request.endpoint)
+
+			// Assert
+			if code != http.StatusNotFound {
+				t.Errorf("expected %s %s to return %d, got %d", request.method, request.endpoint, http.StatusNotFound, code)
+			}
+		}
+	})
+
+}
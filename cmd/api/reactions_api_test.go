@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestReactions(t *testing.T) {
+
+	t.Run("should not allow unauthenticated users to react to a post", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("PUT", "/v1/posts/1/reactions/like", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("should reject unknown reaction kinds", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("PUT", "/v1/posts/1/reactions/dislike", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := generateTokenForUser(1, app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+}
@@ -15,20 +15,28 @@ import (
 	"github.com/d4rthvadr/dusky-go/internal/utils/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func newTestApplication(t *testing.T) *application {
 
 	t.Helper()
 
+	return newTestApplicationWith(t, store.NewMockStore(), &mailer.MockMailer{})
+}
+
+// newTestApplicationWith builds the test application on the given store and mailer, for tests that need the
+// store to fail or want to inspect the mail that was sent.
+func newTestApplicationWith(t *testing.T, mockStore store.Storage, mockMailer mailer.Client) *application {
+
+	t.Helper()
+
 	//logger := logger.NewLoggerMock()
 	logger := logger.NewLogger()
-	mockStore := store.NewMockStore()
 	mockCache := cache.NewMockCache()
-	mockMailer := &mailer.MockMailer{}
 
 	// Create JWT authenticator with test secret
-	jwtAuthenticator := auth.NewJWTAuthenticator("test-secret-key", "test-audience", "test-issuer", 3600)
+	jwtAuthenticator := auth.NewJWTAuthenticator("test-secret-key", "test-audience", "test-issuer", time.Hour)
 
 	return &application{
 		store:            mockStore,
@@ -44,18 +52,32 @@ func newTestApplication(t *testing.T) *application {
 			Mailer:           mockMailer,
 			JWTAuthenticator: jwtAuthenticator,
 			IsProdEnv:        false,
+			PostConfig: config.PostConfig{
+				RestoreGracePeriod: time.Hour * 24 * 7,
+			},
+			LoginConfig: config.LoginConfig{
+				MaxFailedAttempts:  5,
+				FailureWindow:      time.Hour,
+				LockoutDuration:    time.Minute,
+				MaxLockoutDuration: time.Minute * 30,
+			},
 		}),
 	}
 
 }
 
-func generateTokenForUser(userID int64, jwtAuthenticator *auth.JWTAuthenticator) (string, error) {
+func generateTokenForUser(userID int64, jwtAuthenticator auth.Authenticator) (string, error) {
+
+	now := time.Now()
 
 	claims := jwt.MapClaims{
 		"sub": userID,
-		"aud": jwtAuthenticator.Aud,
-		"iss": jwtAuthenticator.Iss,
-		"exp": time.Now().Unix() + jwtAuthenticator.Exp,
+		"aud": jwtAuthenticator.Audience(),
+		"iss": jwtAuthenticator.Issuer(),
+		"jti": uuid.New().String(),
+		"sid": uuid.New().String(),
+		"iat": now.Unix(),
+		"exp": now.Add(jwtAuthenticator.TokenExpiry()).Unix(),
 	}
 
 	return jwtAuthenticator.GenerateToken(claims)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPersonalAccessTokens(t *testing.T) {
+
+	// the mock store accepts any personal access token as a posts:read token of user 1
+	const personalAccessToken = "dsk_pat_test"
+
+	t.Run("should not allow a personal access token without the required scope", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/users/feed", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", "Bearer "+personalAccessToken)
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should not allow a personal access token to manage tokens", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/users/me/tokens", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", "Bearer "+personalAccessToken)
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should not allow a personal access token to reach the admin API", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		// Act
+		request, err := http.NewRequest("GET", "/v1/admin/roles", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", "Bearer "+personalAccessToken)
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+}
@@ -1,11 +1,44 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
 )
 
+// emailChangeUserStore fails the email change calls of the mock user store with the configured errors.
+type emailChangeUserStore struct {
+	*store.UserStoreMock
+	createErr  error
+	confirmErr error
+}
+
+func (s *emailChangeUserStore) CreateEmailChange(context.Context, int64, string, string, time.Duration) error {
+	return s.createErr
+}
+
+func (s *emailChangeUserStore) ConfirmEmailChange(context.Context, string, *models.User) error {
+	return s.confirmErr
+}
+
+func newEmailChangeTestApplication(t *testing.T, users *emailChangeUserStore) (*application, *mailer.MockMailer) {
+	t.Helper()
+
+	mockStore := store.NewMockStore()
+	mockStore.Users = users
+	mockMailer := &mailer.MockMailer{}
+
+	return newTestApplicationWith(t, mockStore, mockMailer), mockMailer
+}
+
 func TestUser(t *testing.T) {
 
 	t.Run("should not allow unauthenticated users to access user details", func(t *testing.T) {
@@ -56,4 +89,280 @@ func TestUser(t *testing.T) {
 		checkResponseCode(t, http.StatusOK, response.Code)
 	})
 
+	t.Run("should reject a profile update with an invalid avatar url", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(`{"avatar_url": "not a url"}`)
+
+		// Act
+		request, err := http.NewRequest("PATCH", "/v1/users/me", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should reject a password change when the confirmation does not match", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(`{"current_password": "old-password", "password": "new-password-1", "confirm_password": "new-password-2"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/me/password", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should refuse a password change with the wrong current password", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(`{"current_password": "not-the-password", "password": "new-password-1", "confirm_password": "new-password-1"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/me/password", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should not allow a personal access token to edit the profile", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"bio": "hello"}`)
+
+		// Act
+		request, err := http.NewRequest("PATCH", "/v1/users/me", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", "Bearer dsk_pat_test")
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("should reject an email change to an invalid address", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(`{"email": "not-an-email", "current_password": "password"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/me/email", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should confirm an email change without authentication", func(t *testing.T) {
+
+		// Arrange
+		app := newTestApplication(t)
+		mux := app.mount()
+
+		body := strings.NewReader(`{"token": "confirmation-token"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/email/confirm", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusNoContent, response.Code)
+	})
+
+	t.Run("should mail the new address and notify the current one of an email change", func(t *testing.T) {
+
+		// Arrange
+		app, mockMailer := newEmailChangeTestApplication(t, &emailChangeUserStore{UserStoreMock: &store.UserStoreMock{}})
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(fmt.Sprintf(`{"email": "new@example.com", "current_password": %q}`, store.MockUserPassword))
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/me/email", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusAccepted, response.Code)
+
+		sent := mockMailer.Sent()
+		if len(sent) != 2 {
+			t.Fatalf("expected 2 emails, got %d", len(sent))
+		}
+		if sent[0].Template != mailer.TemplateEmailChange || sent[0].Email != "new@example.com" {
+			t.Errorf("expected the confirmation link to go to the new address, got %s to %s", sent[0].Template, sent[0].Email)
+		}
+		if sent[1].Template != mailer.TemplateEmailChanged || sent[1].Email != "mock@example.com" {
+			t.Errorf("expected the notice to go to the current address, got %s to %s", sent[1].Template, sent[1].Email)
+		}
+	})
+
+	t.Run("should refuse an email change with the wrong current password", func(t *testing.T) {
+
+		// Arrange
+		app, mockMailer := newEmailChangeTestApplication(t, &emailChangeUserStore{UserStoreMock: &store.UserStoreMock{}})
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(`{"email": "new@example.com", "current_password": "not-the-password"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/me/email", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+
+		if sent := mockMailer.Sent(); len(sent) != 0 {
+			t.Errorf("expected no emails, got %d", len(sent))
+		}
+	})
+
+	t.Run("should refuse an email change to an address in use", func(t *testing.T) {
+
+		// Arrange
+		app, _ := newEmailChangeTestApplication(t, &emailChangeUserStore{
+			UserStoreMock: &store.UserStoreMock{},
+			createErr:     errCustom.ErrConflict,
+		})
+		mux := app.mount()
+
+		token, err := generateTokenForUser(int64(1), app.jwtAuthenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := strings.NewReader(fmt.Sprintf(`{"email": "taken@example.com", "current_password": %q}`, store.MockUserPassword))
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/me/email", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("should reject an unknown or expired email confirmation token", func(t *testing.T) {
+
+		// Arrange
+		app, _ := newEmailChangeTestApplication(t, &emailChangeUserStore{
+			UserStoreMock: &store.UserStoreMock{},
+			confirmErr:    errCustom.ErrResourceNotFound,
+		})
+		mux := app.mount()
+
+		body := strings.NewReader(`{"token": "expired-token"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/email/confirm", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should refuse to confirm an email another account took in the meantime", func(t *testing.T) {
+
+		// Arrange
+		app, _ := newEmailChangeTestApplication(t, &emailChangeUserStore{
+			UserStoreMock: &store.UserStoreMock{},
+			confirmErr:    errCustom.ErrConflict,
+		})
+		mux := app.mount()
+
+		body := strings.NewReader(`{"token": "confirmation-token"}`)
+
+		// Act
+		request, err := http.NewRequest("POST", "/v1/users/email/confirm", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := executeRequest(mux, request)
+
+		// Assert
+		checkResponseCode(t, http.StatusConflict, response.Code)
+	})
+
 }
@@ -1,8 +1,24 @@
 package auth
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Authenticator issues and verifies the API's tokens. New tokens are signed with the key identified by
+// SigningKeyID, while every key published in JWKS keeps verifying, so signing keys can be rotated.
 type Authenticator interface {
 	GenerateToken(claims jwt.Claims) (string, error)
 	ValidateToken(token string) (*jwt.Token, error)
+	// SigningKeyID is the kid header of newly issued tokens.
+	SigningKeyID() string
+	// JWKS returns the public keys that verify issued tokens.
+	JWKS() JWKS
+	GetAccessClaims(token *jwt.Token) (*AccessClaims, error)
+	GetMFAPendingUserID(token *jwt.Token) (int64, error)
+	// Audience and Issuer are the aud and iss claims of issued tokens, and TokenExpiry the lifetime of access tokens.
+	Audience() string
+	Issuer() string
+	TokenExpiry() time.Duration
 }
@@ -2,22 +2,30 @@ package auth
 
 import (
 	"errors"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// LegacyKeyID identifies the HS256 secret configured through NewJWTAuthenticator. Tokens signed before key ids
+// were introduced carry no kid and are verified with this key.
+const LegacyKeyID = "default"
+
+// TokenTypeMFAPending is the typ claim of the short-lived token handed out after the password of an account with
+// two-factor authentication was verified. It only allows completing the login, never calling the API.
+const TokenTypeMFAPending = "mfa_pending"
+
+var _ Authenticator = (*JWTAuthenticator)(nil)
+
 type JWTAuthenticator struct {
-	secretKey string
-	Aud       string
-	Iss       string
-	Exp       int64
+	keys *KeySet
+	Aud  string
+	Iss  string
+	// Exp is how long an access token stays valid after it is issued.
+	Exp time.Duration
 }
 
-func validateJWTConfig(secretKey, aud, iss string, exp int64) {
-
-	if secretKey == "" {
-		panic("secret key cannot be empty")
-	}
+func validateJWTConfig(aud, iss string, exp time.Duration) {
 
 	if aud == "" {
 		panic("audience cannot be empty")
@@ -31,33 +39,83 @@ func validateJWTConfig(secretKey, aud, iss string, exp int64) {
 		panic("expiration time must be greater than zero")
 	}
 }
-func NewJWTAuthenticator(secretKey, aud, iss string, exp int64) *JWTAuthenticator {
 
-	validateJWTConfig(secretKey, aud, iss, exp)
+// NewJWTAuthenticator creates an authenticator that signs and verifies HS256 tokens with a single shared secret.
+func NewJWTAuthenticator(secretKey, aud, iss string, exp time.Duration) *JWTAuthenticator {
+
+	if secretKey == "" {
+		panic("secret key cannot be empty")
+	}
+
+	keys, err := NewKeySet(LegacyKeyID, NewHMACKey(LegacyKeyID, secretKey))
+	if err != nil {
+		panic(err)
+	}
+
+	return NewJWTAuthenticatorWithKeys(keys, aud, iss, exp)
+}
+
+// NewJWTAuthenticatorWithKeys creates an authenticator that signs with the key set's signing key and verifies
+// tokens signed by any key in the set, so keys can be rotated while tokens signed by the previous key are still valid.
+func NewJWTAuthenticatorWithKeys(keys *KeySet, aud, iss string, exp time.Duration) *JWTAuthenticator {
 
-	return &JWTAuthenticator{secretKey: secretKey, Aud: aud, Iss: iss, Exp: exp}
+	validateJWTConfig(aud, iss, exp)
+
+	return &JWTAuthenticator{keys: keys, Aud: aud, Iss: iss, Exp: exp}
 }
 
 func (j *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signing := j.keys.signing
+
+	token := jwt.NewWithClaims(signing.Method, claims)
+	token.Header["kid"] = signing.ID
 
-	return token.SignedString([]byte(j.secretKey))
+	return token.SignedString(signing.signKey)
 }
 
 // ValidateToken validates the given JWT token string and returns the parsed token if valid.
+// The kid header selects the verifying key and the token algorithm must match that key.
 func (j *JWTAuthenticator) ValidateToken(tokenStr string) (*jwt.Token, error) {
 	return jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = LegacyKeyID
 		}
-		return []byte(j.secretKey), nil
+
+		key, err := j.keys.lookup(kid, token.Method.Alg())
+		if err != nil {
+			return nil, err
+		}
+		return key.verifyKey, nil
 	},
 		jwt.WithAudience(j.Aud),
 		jwt.WithExpirationRequired(),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithValidMethods(j.keys.methods()),
 	)
 }
 
+// SigningKeyID returns the kid of the key new tokens are signed with.
+func (j *JWTAuthenticator) SigningKeyID() string {
+	return j.keys.signing.ID
+}
+
+func (j *JWTAuthenticator) Audience() string {
+	return j.Aud
+}
+
+func (j *JWTAuthenticator) Issuer() string {
+	return j.Iss
+}
+
+func (j *JWTAuthenticator) TokenExpiry() time.Duration {
+	return j.Exp
+}
+
+// JWKS returns the public keys that verify tokens issued by this authenticator.
+func (j *JWTAuthenticator) JWKS() JWKS {
+	return j.keys.JWKS()
+}
+
 // GetUserIDFromClaims extracts the user ID from the "sub" claim in the JWT token claims.
 func (j *JWTAuthenticator) GetUserIDFromClaims(token *jwt.Token) (int64, error) {
 
@@ -76,3 +134,68 @@ func (j *JWTAuthenticator) GetUserIDFromClaims(token *jwt.Token) (int64, error)
 
 	return userID, nil
 }
+
+// AccessClaims are the claims the API relies on once an access token has been validated.
+type AccessClaims struct {
+	UserID    int64
+	ID        string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// SessionID is the sid claim, the session the token was issued to. It is empty for tokens issued
+	// before sessions were tracked.
+	SessionID string
+}
+
+// GetAccessClaims extracts the subject, token ID (jti), issue time and expiry from a validated token.
+// Tokens without a jti or iat cannot be revoked and are rejected, as are tokens of another type such as MFA pending tokens.
+func (j *JWTAuthenticator) GetAccessClaims(token *jwt.Token) (*AccessClaims, error) {
+	userID, err := j.GetUserIDFromClaims(token)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+
+	if typ, _ := claims["typ"].(string); typ != "" {
+		return nil, errors.New("invalid token: not an access token")
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, errors.New("invalid token claims: missing 'jti'")
+	}
+
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return nil, errors.New("invalid token claims: missing 'iat'")
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, errors.New("invalid token claims: missing 'exp'")
+	}
+
+	sessionID, _ := claims["sid"].(string)
+
+	return &AccessClaims{
+		UserID:    userID,
+		ID:        jti,
+		IssuedAt:  issuedAt.Time,
+		ExpiresAt: expiresAt.Time,
+		SessionID: sessionID,
+	}, nil
+}
+
+// GetMFAPendingUserID returns the user a validated MFA pending token was issued to. Any other token is rejected.
+func (j *JWTAuthenticator) GetMFAPendingUserID(token *jwt.Token) (int64, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, errors.New("invalid token claims")
+	}
+
+	if typ, _ := claims["typ"].(string); typ != TokenTypeMFAPending {
+		return 0, errors.New("invalid token: not an MFA pending token")
+	}
+
+	return j.GetUserIDFromClaims(token)
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims(aud string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": 1,
+		"aud": aud,
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func newRSAKey(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := x509.MarshalPKCS1PrivateKey(private)
+
+	key, err := ParsePEMKey(kid, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParsePEMKey(kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestJWTAuthenticator_SignsAndVerifiesWithEachAlgorithm(t *testing.T) {
+	for _, key := range []*SigningKey{newRSAKey(t, "rsa"), newEd25519Key(t, "ed"), NewHMACKey("hmac", "secret")} {
+		keys, err := NewKeySet(key.ID, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authenticator := NewJWTAuthenticatorWithKeys(keys, "aud", "iss", time.Minute)
+
+		token, err := authenticator.GenerateToken(testClaims("aud"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := authenticator.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("%s: expected token to validate, got %v", key.Method.Alg(), err)
+		}
+		if parsed.Header["kid"] != key.ID {
+			t.Fatalf("%s: expected kid %q, got %v", key.Method.Alg(), key.ID, parsed.Header["kid"])
+		}
+	}
+}
+
+func TestJWTAuthenticator_RotationKeepsOldTokensValid(t *testing.T) {
+	oldKey, newKey := newRSAKey(t, "2024"), newEd25519Key(t, "2025")
+
+	before, err := NewKeySet("2024", oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewJWTAuthenticatorWithKeys(before, "aud", "iss", time.Minute).GenerateToken(testClaims("aud"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := NewKeySet("2025", oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewJWTAuthenticatorWithKeys(after, "aud", "iss", time.Minute).ValidateToken(token); err != nil {
+		t.Fatalf("expected token signed by the previous key to validate, got %v", err)
+	}
+
+	retired, err := NewKeySet("2025", newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewJWTAuthenticatorWithKeys(retired, "aud", "iss", time.Minute).ValidateToken(token); err == nil {
+		t.Fatalf("expected token signed by a removed key to be rejected")
+	}
+}
+
+func TestJWTAuthenticator_RejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	keys, err := NewKeySet("rsa", rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator := NewJWTAuthenticatorWithKeys(keys, "aud", "iss", time.Minute)
+
+	// an HS256 token claiming the RSA kid must not be verified with the public key as an HMAC secret
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims("aud"))
+	forged.Header["kid"] = "rsa"
+	token, err := forged.SignedString([]byte("anything"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authenticator.ValidateToken(token); err == nil {
+		t.Fatalf("expected a token with a mismatched algorithm to be rejected")
+	}
+}
+
+func TestKeySet_JWKSPublishesOnlyPublicKeys(t *testing.T) {
+	keys, err := NewKeySet("rsa", newRSAKey(t, "rsa"), newEd25519Key(t, "ed"), NewHMACKey(LegacyKeyID, "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected the HMAC secret to be left out, got %d keys", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kid != "ed" || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].X == "" {
+		t.Fatalf("unexpected Ed25519 JWK %+v", jwks.Keys[0])
+	}
+	if jwks.Keys[1].Kid != "rsa" || jwks.Keys[1].Kty != "RSA" || jwks.Keys[1].N == "" || jwks.Keys[1].E != "AQAB" {
+		t.Fatalf("unexpected RSA JWK %+v", jwks.Keys[1])
+	}
+}
+
+func TestNewKeySet_RequiresPrivateSigningKey(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := ParsePEMKey("public", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewKeySet("public", public); err == nil {
+		t.Fatalf("expected a verify-only key to be rejected as the signing key")
+	}
+}
+
+func TestJWTAuthenticator_TokenTypesAreNotInterchangeable(t *testing.T) {
+	authenticator := NewJWTAuthenticator("secret", "aud", "iss", time.Minute)
+
+	claims := testClaims("aud")
+	claims["jti"] = "pending"
+	claims["iat"] = time.Now().Unix()
+	claims["typ"] = TokenTypeMFAPending
+
+	pending, err := authenticator.GenerateToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := authenticator.ValidateToken(pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authenticator.GetAccessClaims(token); err == nil {
+		t.Fatalf("expected an MFA pending token to be rejected as an access token")
+	}
+	if userID, err := authenticator.GetMFAPendingUserID(token); err != nil || userID != 1 {
+		t.Fatalf("expected the MFA pending token to carry user 1, got %d, %v", userID, err)
+	}
+
+	delete(claims, "typ")
+	access, err := authenticator.GenerateToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = authenticator.ValidateToken(access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authenticator.GetMFAPendingUserID(token); err == nil {
+		t.Fatalf("expected an access token to be rejected as an MFA pending token")
+	}
+}
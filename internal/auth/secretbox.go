@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SecretBoxKeySize is the size of the AES-256 key a SecretBox is created with.
+const SecretBoxKeySize = 32
+
+// sealedPrefix marks the format of a sealed value, so the format can change without breaking stored values.
+const sealedPrefix = "v1."
+
+var errSealedValueInvalid = errors.New("sealed value is invalid or was sealed for something else")
+
+// SecretBox encrypts small secrets, such as TOTP secrets, with AES-256-GCM before they are stored, so reading
+// the database alone does not reveal them.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox returns a SecretBox using key, which must be SecretBoxKeySize bytes long.
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	if len(key) != SecretBoxKeySize {
+		return nil, fmt.Errorf("secret box key must be %d bytes, got %d", SecretBoxKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal encrypts plaintext. The sealed value only opens with the same additionalData, which binds it to its
+// owner so it cannot be copied to another row.
+func (b *SecretBox) Seal(plaintext string, additionalData []byte) string {
+	nonce := make([]byte, b.aead.NonceSize())
+	// crypto/rand.Read never returns an error and always fills nonce
+	rand.Read(nonce)
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), additionalData)
+
+	return sealedPrefix + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// Open decrypts a value returned by Seal with the same additionalData.
+func (b *SecretBox) Open(value string, additionalData []byte) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return "", errSealedValueInvalid
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", errSealedValueInvalid
+	}
+
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return "", errSealedValueInvalid
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecretBox(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{1}, SecretBoxKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := GenerateTOTPSecret()
+	owner := []byte("user:1")
+
+	sealed := box.Seal(secret, owner)
+
+	t.Run("opens with the same additional data", func(t *testing.T) {
+		opened, err := box.Open(sealed, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened != secret {
+			t.Fatalf("expected %s, got %s", secret, opened)
+		}
+	})
+
+	t.Run("does not store the plaintext", func(t *testing.T) {
+		if bytes.Contains([]byte(sealed), []byte(secret)) {
+			t.Fatalf("sealed value %q exposes the secret", sealed)
+		}
+	})
+
+	t.Run("does not open for another owner", func(t *testing.T) {
+		if _, err := box.Open(sealed, []byte("user:2")); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("does not open with another key", func(t *testing.T) {
+		other, err := NewSecretBox(bytes.Repeat([]byte{2}, SecretBoxKeySize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.Open(sealed, owner); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("does not open a plaintext secret", func(t *testing.T) {
+		if _, err := box.Open(secret, owner); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestNewSecretBox_RejectsShortKeys(t *testing.T) {
+	if _, err := NewSecretBox([]byte("too short")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
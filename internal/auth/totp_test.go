@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 test key of RFC 4226 and RFC 6238.
+var rfcSecret = []byte("12345678901234567890")
+
+func TestHOTP_RFC4226Vectors(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		if got := hotp(rfcSecret, uint64(counter), 6); got != code {
+			t.Fatalf("counter %d: expected %s, got %s", counter, code, got)
+		}
+	}
+}
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		step := TOTPStep(time.Unix(v.unix, 0))
+		if got := hotp(rfcSecret, uint64(step), 8); got != v.code {
+			t.Fatalf("time %d: expected %s, got %s", v.unix, v.code, got)
+		}
+	}
+}
+
+func TestValidateTOTP_AcceptsNeighbouringSteps(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcSecret)
+	now := time.Unix(1111111111, 0)
+
+	code, err := TOTPCode(secret, now.Add(-TOTPPeriod))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateTOTP(secret, code, now)
+	if !ok {
+		t.Fatalf("expected the code of the previous step to be accepted")
+	}
+	if step != TOTPStep(now)-1 {
+		t.Fatalf("expected the matched step to be the previous one, got %d", step)
+	}
+
+	if _, ok := ValidateTOTP(secret, code, now.Add(2*TOTPPeriod)); ok {
+		t.Fatalf("expected a code three steps old to be rejected")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Fatalf("expected a code with the wrong length to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret := GenerateTOTPSecret()
+
+	uri, err := url.Parse(TOTPURI("Dusky", "jane@example.com", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Dusky:jane@example.com" {
+		t.Fatalf("unexpected otpauth URI %s", uri)
+	}
+	if uri.Query().Get("secret") != secret || uri.Query().Get("issuer") != "Dusky" {
+		t.Fatalf("unexpected otpauth parameters %s", uri.RawQuery)
+	}
+}
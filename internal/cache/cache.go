@@ -13,10 +13,16 @@ type CacheStorage struct {
 		Set(context.Context, *models.User, time.Duration) error
 		Delete(context.Context, int64) error
 	}
+	Tokens interface {
+		Revoke(context.Context, string, time.Time) error
+		RevokeAllForUser(context.Context, int64, time.Time, time.Time) error
+		IsRevoked(context.Context, string, int64, time.Time) (bool, error)
+	}
 }
 
 func NewCache(rdb *RedisClient) CacheStorage {
 	return CacheStorage{
-		Users: &UserCache{rdb: rdb},
+		Users:  &UserCache{rdb: rdb},
+		Tokens: &TokenCache{rdb: rdb},
 	}
 }
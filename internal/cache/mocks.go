@@ -5,19 +5,22 @@ import (
 	"time"
 
 	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/redis/go-redis/v9"
 )
 
 func NewMockCache() CacheStorage {
 	return CacheStorage{
-		Users: &UserCacheMock{},
+		Users:  &UserCacheMock{},
+		Tokens: &TokenCacheMock{},
 	}
 }
 
+// UserCacheMock never holds a user, so users are always read from the store.
 type UserCacheMock struct {
 }
 
 func (m *UserCacheMock) Get(context.Context, int64) (*models.User, error) {
-	return nil, nil
+	return nil, redis.Nil
 }
 func (m *UserCacheMock) Set(context.Context, *models.User, time.Duration) error {
 	return nil
@@ -25,3 +28,16 @@ func (m *UserCacheMock) Set(context.Context, *models.User, time.Duration) error
 func (m *UserCacheMock) Delete(context.Context, int64) error {
 	return nil
 }
+
+type TokenCacheMock struct {
+}
+
+func (m *TokenCacheMock) Revoke(context.Context, string, time.Time) error {
+	return nil
+}
+func (m *TokenCacheMock) RevokeAllForUser(context.Context, int64, time.Time, time.Time) error {
+	return nil
+}
+func (m *TokenCacheMock) IsRevoked(context.Context, string, int64, time.Time) (bool, error) {
+	return false, nil
+}
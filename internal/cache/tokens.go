@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenCache keeps access token revocations in Redis. Entries expire together with the tokens they revoke.
+type TokenCache struct {
+	rdb *RedisClient
+}
+
+func getRevokedTokenCacheKey(jti string) string {
+	return fmt.Sprintf("revoked-token-%s", jti)
+}
+
+func getUserRevocationCacheKey(userID int64) string {
+	return fmt.Sprintf("revoked-user-tokens-%v", userID)
+}
+
+// Revoke revokes a single access token until it expires.
+func (c *TokenCache) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return c.rdb.Set(ctx, getRevokedTokenCacheKey(jti), 1, ttl)
+}
+
+// RevokeAllForUser revokes every access token of the user issued in a second before the one of revokedBefore.
+// The entry is kept until expiresAt, by which time every such token has expired.
+func (c *TokenCache) RevokeAllForUser(ctx context.Context, userID int64, revokedBefore, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return c.rdb.Set(ctx, getUserRevocationCacheKey(userID), revokedBefore.Unix(), ttl)
+}
+
+// IsRevoked reports whether the token has been revoked on its own or by a revocation of all the user's tokens.
+func (c *TokenCache) IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error) {
+	_, err := c.rdb.Get(ctx, getRevokedTokenCacheKey(jti))
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	data, err := c.rdb.Get(ctx, getUserRevocationCacheKey(userID))
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	dataStr, ok := data.(string)
+	if !ok {
+		return false, fmt.Errorf("unexpected revocation entry for user %d", userID)
+	}
+
+	revokedBefore, err := strconv.ParseInt(dataStr, 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	// iat has whole seconds, so only tokens issued in an earlier second count as issued before the revocation.
+	// Tokens issued earlier in its own second are refused anyway, as their session was revoked with it.
+	return issuedAt.Unix() < revokedBefore, nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	env "github.com/d4rthvadr/dusky-go/internal/utils"
@@ -23,17 +25,32 @@ type sendGridConfig struct {
 }
 
 type MailConfig struct {
-	Expiry    time.Duration
-	FromEmail string
-	ApiUrl    string
-	SendGrid  sendGridConfig
+	Expiry time.Duration
+	// PasswordResetExpiry is how long a password reset link stays valid.
+	PasswordResetExpiry time.Duration
+	// MagicLinkExpiry is how long a passwordless login link stays valid.
+	MagicLinkExpiry time.Duration
+	// MagicLinkURL is the client page login links open. It gets the token as the token query parameter and
+	// exchanges it at POST /auth/magic-link/consume.
+	MagicLinkURL string
+	// EmailChangeExpiry is how long the link confirming a new email address stays valid.
+	EmailChangeExpiry time.Duration
+	FromEmail         string
+	ApiUrl            string
+	SendGrid          sendGridConfig
 }
 
 type JWTConfig struct {
 	SecretKey string
 	Audience  string
 	Issuer    string
-	Expiry    time.Duration
+	// KeyFiles maps key ids to PEM key files. When set, tokens are signed with SigningKeyID instead of SecretKey;
+	// the other keys, and SecretKey if given, only verify tokens issued before a rotation.
+	KeyFiles     map[string]string
+	SigningKeyID string
+	// Expiry is the lifetime of access tokens; RefreshExpiry the lifetime of the refresh tokens that renew them.
+	Expiry        time.Duration
+	RefreshExpiry time.Duration
 }
 
 type CacheConfig struct {
@@ -42,15 +59,82 @@ type CacheConfig struct {
 	DB       int
 	Enabled  bool
 }
+type PostConfig struct {
+	// RestoreGracePeriod is how long after a soft delete the owner can still restore a post.
+	RestoreGracePeriod time.Duration
+}
+
+// LoginConfig controls how failed logins are throttled. After MaxFailedAttempts consecutive failures within
+// FailureWindow an account is locked for LockoutDuration, doubling with every further failure up to MaxLockoutDuration.
+// Independently, a client IP may try to log in IPMaxAttempts times per IPWindow, at most MagicLinkMaxSends
+// login links are emailed to an address per MagicLinkWindow, at most PasswordResetMaxSends password reset
+// links per PasswordResetWindow, and at most ActivationMaxSends activation links per ActivationWindow.
+type LoginConfig struct {
+	MaxFailedAttempts  int
+	FailureWindow      time.Duration
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+	IPMaxAttempts      int
+	IPWindow           time.Duration
+	MagicLinkMaxSends  int
+	MagicLinkWindow    time.Duration
+	// PasswordResetMaxSends and PasswordResetWindow limit password reset emails per address.
+	PasswordResetMaxSends int
+	PasswordResetWindow   time.Duration
+	// ActivationMaxSends and ActivationWindow limit resent activation emails per address.
+	ActivationMaxSends int
+	ActivationWindow   time.Duration
+}
+
+// UserConfig controls the lifecycle of accounts that were registered but never activated.
+type UserConfig struct {
+	// UnactivatedRetention is how long an unactivated account is kept after its last invitation expired.
+	UnactivatedRetention time.Duration
+	// CleanupInterval is how often unactivated accounts past their retention are deleted.
+	CleanupInterval time.Duration
+}
+
+// PasswordConfig controls how passwords are hashed and which passwords users may choose. New hashes use
+// HashAlgorithm (argon2id or bcrypt); hashes made with the other algorithm or older parameters are upgraded
+// on the next successful login. Argon2Memory is in KiB. BreachListFile optionally names a local list of
+// breached passwords that are refused.
+type PasswordConfig struct {
+	HashAlgorithm     string
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+	MinLength         int
+	BreachListFile    string
+}
+
+// RoleConfig controls how role permissions are cached. PermissionCacheTTL bounds how long a permission change
+// made on another instance takes to apply; zero disables the cache.
+type RoleConfig struct {
+	PermissionCacheTTL time.Duration
+}
+
+// MFAConfig holds the key TOTP secrets are encrypted with before they are stored. TOTPEncryptionKey is the
+// base64 encoding of 32 random bytes.
+type MFAConfig struct {
+	TOTPEncryptionKey string
+}
+
 type AppConfig struct {
 	Server      serverConfig
 	Db          dbConfig
 	Mail        MailConfig
 	JWT         JWTConfig
+	MFA         MFAConfig
 	Environment string
 	ApiUrl      string
 	CacheConfig CacheConfig
 	RateLimiter RateLimiterConfig
+	Post        PostConfig
+	User        UserConfig
+	Login       LoginConfig
+	Role        RoleConfig
+	Password    PasswordConfig
 }
 
 type RateLimiterConfig struct {
@@ -74,7 +158,12 @@ func InitializeConfig() (*AppConfig, error) {
 	jwtSecretKey := env.GetEnv("JWT_SECRET_KEY", "")
 	jwtAudience := env.GetEnv("JWT_AUDIENCE", "")
 	jwtIssuer := env.GetEnv("JWT_ISSUER", "")
-	jwtExpiry := env.GetEnvAsDuration("JWT_EXPIRY", time.Hour*24)
+	jwtExpiry := env.GetEnvAsDuration("JWT_EXPIRY", time.Minute*15)
+	jwtRefreshExpiry := env.GetEnvAsDuration("JWT_REFRESH_EXPIRY", time.Hour*24*30)
+	jwtKeyFiles, err := parseKeyFiles(env.GetEnv("JWT_KEY_FILES", ""))
+	if err != nil {
+		return nil, err
+	}
 
 	config := &AppConfig{
 		Server: serverConfig{
@@ -88,18 +177,28 @@ func InitializeConfig() (*AppConfig, error) {
 		},
 		ApiUrl: apiUrl,
 		Mail: MailConfig{
-			Expiry:    mailExpiry,
-			FromEmail: fromEmail,
-			ApiUrl:    apiUrl,
+			Expiry:              mailExpiry,
+			PasswordResetExpiry: env.GetEnvAsDuration("MAIL_PASSWORD_RESET_EXPIRY", time.Hour),
+			MagicLinkExpiry:     env.GetEnvAsDuration("MAIL_MAGIC_LINK_EXPIRY", time.Minute*15),
+			MagicLinkURL:        env.GetEnv("MAIL_MAGIC_LINK_URL", "http://localhost:3000/login/magic-link"),
+			EmailChangeExpiry:   env.GetEnvAsDuration("MAIL_EMAIL_CHANGE_EXPIRY", time.Hour*24),
+			FromEmail:           fromEmail,
+			ApiUrl:              apiUrl,
 			SendGrid: sendGridConfig{
 				APIKey: sendGridAPIKey,
 			},
 		},
 		JWT: JWTConfig{
-			SecretKey: jwtSecretKey,
-			Audience:  jwtAudience,
-			Issuer:    jwtIssuer,
-			Expiry:    jwtExpiry,
+			SecretKey:     jwtSecretKey,
+			Audience:      jwtAudience,
+			Issuer:        jwtIssuer,
+			Expiry:        jwtExpiry,
+			RefreshExpiry: jwtRefreshExpiry,
+			KeyFiles:      jwtKeyFiles,
+			SigningKeyID:  env.GetEnv("JWT_SIGNING_KEY_ID", ""),
+		},
+		MFA: MFAConfig{
+			TOTPEncryptionKey: env.GetEnv("MFA_TOTP_ENCRYPTION_KEY", ""),
 		},
 		Environment: environment,
 		CacheConfig: CacheConfig{
@@ -113,6 +212,59 @@ func InitializeConfig() (*AppConfig, error) {
 			TimeFrame:            env.GetEnvAsDuration("RATE_LIMITER_TIME_FRAME", time.Minute),
 			Enabled:              env.GetEnvAsBool("RATE_LIMITER_ENABLED", true),
 		},
+		Post: PostConfig{
+			RestoreGracePeriod: env.GetEnvAsDuration("POST_RESTORE_GRACE_PERIOD", time.Hour*24*7),
+		},
+		User: UserConfig{
+			UnactivatedRetention: env.GetEnvAsDuration("USER_UNACTIVATED_RETENTION", time.Hour*24*7),
+			CleanupInterval:      env.GetEnvAsDuration("USER_CLEANUP_INTERVAL", time.Hour),
+		},
+		Login: LoginConfig{
+			MaxFailedAttempts:     env.GetEnvAsInt("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+			FailureWindow:         env.GetEnvAsDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+			LockoutDuration:       env.GetEnvAsDuration("LOGIN_LOCKOUT_DURATION", time.Minute),
+			MaxLockoutDuration:    env.GetEnvAsDuration("LOGIN_MAX_LOCKOUT_DURATION", time.Minute*30),
+			IPMaxAttempts:         env.GetEnvAsInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+			IPWindow:              env.GetEnvAsDuration("LOGIN_IP_WINDOW", time.Minute*15),
+			MagicLinkMaxSends:     env.GetEnvAsInt("LOGIN_MAGIC_LINK_MAX_SENDS", 3),
+			MagicLinkWindow:       env.GetEnvAsDuration("LOGIN_MAGIC_LINK_WINDOW", time.Minute*15),
+			PasswordResetMaxSends: env.GetEnvAsInt("LOGIN_PASSWORD_RESET_MAX_SENDS", 3),
+			PasswordResetWindow:   env.GetEnvAsDuration("LOGIN_PASSWORD_RESET_WINDOW", time.Minute*15),
+			ActivationMaxSends:    env.GetEnvAsInt("LOGIN_ACTIVATION_RESEND_MAX_SENDS", 3),
+			ActivationWindow:      env.GetEnvAsDuration("LOGIN_ACTIVATION_RESEND_WINDOW", time.Minute*15),
+		},
+		Password: PasswordConfig{
+			HashAlgorithm:     env.GetEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      env.GetEnvAsInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+			Argon2Iterations:  env.GetEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: env.GetEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 2),
+			BcryptCost:        env.GetEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+			MinLength:         env.GetEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			BreachListFile:    env.GetEnv("PASSWORD_BREACH_LIST_FILE", ""),
+		},
+		Role: RoleConfig{
+			PermissionCacheTTL: env.GetEnvAsDuration("ROLE_PERMISSION_CACHE_TTL", time.Minute),
+		},
 	}
 	return config, nil
 }
+
+// parseKeyFiles parses a comma separated list of kid=path pairs.
+func parseKeyFiles(value string) (map[string]string, error) {
+	keyFiles := map[string]string{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(kid) == "" || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid JWT_KEY_FILES entry %q, expected kid=path", pair)
+		}
+		keyFiles[strings.TrimSpace(kid)] = strings.TrimSpace(path)
+	}
+
+	return keyFiles, nil
+}
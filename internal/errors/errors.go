@@ -13,6 +13,7 @@ var (
 	ErrInvalidInput     = errors.New("invalid input")
 	ErrInternal         = errors.New("internal server error")
 	ErrConflict         = errors.New("resource already exists")
+	ErrEditConflict     = errors.New("resource has been modified since it was read")
 )
 
 // TODO: map to domain errors
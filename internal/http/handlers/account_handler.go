@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// UpdateProfilePayload changes the fields that are set and leaves the others as they are.
+// An empty display name, bio or avatar URL clears it.
+type UpdateProfilePayload struct {
+	Username    *string `json:"username" validate:"omitnil,min=3,max=50"`
+	DisplayName *string `json:"display_name" validate:"omitnil,max=100"`
+	Bio         *string `json:"bio" validate:"omitnil,max=500"`
+	AvatarURL   *string `json:"avatar_url" validate:"omitnil,max=2048,eq=|http_url"`
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=255"`
+	Password        string `json:"password" validate:"required,min=8,max=255"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+}
+
+// GetCurrentUser godoc
+//
+//	@Summary		Get the authenticated user
+//	@Description	Retrieve the account and profile of the authenticated user.
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	models.User
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me [get]
+func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, user); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UpdateCurrentUser godoc
+//
+//	@Summary		Update the authenticated user's profile
+//	@Description	Change the username, display name, bio or avatar URL of the authenticated user. Fields that are omitted
+//	@Description	keep their value; an empty display name, bio or avatar URL clears it.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		UpdateProfilePayload	true	"Profile fields to change"
+//	@Success		200		{object}	models.User
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me [patch]
+func (h *Handler) UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateProfilePayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	claims, ok := getAccessClaimsFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	// the request context may hold a cached copy; apply the changes to the stored user
+	user, ok := h.getStoredUser(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if payload.Username != nil {
+		user.Username = *payload.Username
+	}
+	if payload.DisplayName != nil {
+		user.DisplayName = *payload.DisplayName
+	}
+	if payload.Bio != nil {
+		user.Bio = *payload.Bio
+	}
+	if payload.AvatarURL != nil {
+		user.AvatarURL = *payload.AvatarURL
+	}
+
+	if err := h.store.Users.UpdateProfile(r.Context(), user); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrConflict):
+			h.conflictError(w, r, errors.New("username is already taken"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.invalidateCachedUser(r.Context(), user.ID)
+
+	if err := writeResponse(w, http.StatusOK, user); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getStoredUser loads the authenticated user from the store rather than the cache. A user deleted since the
+// token was issued gets a 401. When the user cannot be loaded it writes the response and returns false.
+func (h *Handler) getStoredUser(w http.ResponseWriter, r *http.Request, userID int64) (*models.User, bool) {
+	user, err := h.store.Users.GetByID(r.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.unauthorizedError(w, r, errors.New("user no longer exists"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change the authenticated user's password
+//	@Description	Set a new password after confirming the current one. Every other session of the user is signed out and
+//	@Description	every personal access token is revoked; the session of this request stays logged in.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ChangePasswordPayload	true	"Current and new password"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/password [post]
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var payload ChangePasswordPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	claims, ok := getAccessClaimsFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	// cached users do not carry the password hash
+	user, ok := h.getStoredUser(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if !h.confirmCurrentPassword(w, r, user, payload.CurrentPassword) {
+		return
+	}
+
+	if !h.acceptablePassword(w, r, payload.Password) {
+		return
+	}
+
+	oldHash := user.Password.Hash
+	if err := user.Password.Set(payload.Password); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := h.store.Users.UpdatePasswordHash(r.Context(), user.ID, oldHash, user.Password.Hash); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.conflictError(w, r, errors.New("the password was changed by another request"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := h.store.Sessions.RevokeAllExcept(r.Context(), user.ID, claims.SessionID); err != nil {
+		h.logger.Errorf("password changed for user: %d but revoking other sessions failed: %s", user.ID, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+
+	if err := h.store.PersonalAccessTokens.DeleteAllForUser(r.Context(), user.ID); err != nil {
+		h.logger.Errorf("password changed for user: %d but revoking personal access tokens failed: %s", user.ID, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
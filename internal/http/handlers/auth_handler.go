@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
@@ -8,6 +9,8 @@ import (
 	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/mailer"
 	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/passwords"
+	"github.com/d4rthvadr/dusky-go/internal/store"
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/google/uuid"
@@ -30,6 +33,22 @@ type CreateUserTokenPayload struct {
 	Password string `json:"password" validate:"required,min=8,max=255"`
 }
 
+type ResendActivationPayload struct {
+	Email string `json:"email" validate:"required,email,max=120"`
+}
+
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=255"`
+}
+
+// TokenResponse is returned on login and refresh. Token is a short-lived access token; RefreshToken is single use
+// and must be exchanged at /auth/refresh for a new pair before it expires.
+type TokenResponse struct {
+	Token        string `json:"token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 type emailDataEnvelope struct {
 	UserName      string
 	ActivationURL string
@@ -60,6 +79,10 @@ func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.acceptablePassword(w, r, payload.Password) {
+		return
+	}
+
 	userModel := models.User{
 		Username: payload.Username,
 		Email:    payload.Email,
@@ -75,9 +98,7 @@ func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	plainToken := uuid.New().String()
 	hashedToken := hashAndEncodeToken(plainToken)
 
-	invitationExpiry := time.Hour * 24
-
-	if err := h.store.Users.CreateAndInvite(r.Context(), &userModel, hashedToken, invitationExpiry); err != nil {
+	if err := h.store.Users.CreateAndInvite(r.Context(), &userModel, hashedToken, h.mailConfig.Expiry); err != nil {
 
 		h.logger.Errorf("error creating user and invitation: %s error: %s", payload.Email, err.Error())
 		h.internalServerError(w, r, nil)
@@ -118,17 +139,88 @@ func (h *Handler) sendUserInvitationEmail(username, email, token string, isSandB
 
 }
 
+// ResendActivation godoc
+//
+//	@Summary		Resend the activation email
+//	@Description	Send a new activation link to an account that has not been activated yet. Links sent earlier stop working.
+//	@Description	The response is the same whether or not such an account exists, so it cannot be used to discover accounts.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ResendActivationPayload	true	"Account email"
+//	@Success		202		{object}	map[string]string
+//	@Failure		400		{object}	error
+//	@Failure		429		{object}	error
+//	@Router			/auth/activation/resend [post]
+func (h *Handler) ResendActivation(w http.ResponseWriter, r *http.Request) {
+	var payload ResendActivationPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	// the limit applies to every address, known or not, so hitting it does not reveal whether the account exists
+	if h.activationLimiter != nil {
+		if allowed, retryAfter := h.activationLimiter.Allow(normalizeLoginEmail(payload.Email)); !allowed {
+			setRetryAfter(w, retryAfter)
+			h.tooManyRequestsError(w, r, errors.New("too many activation emails requested, please try again later"))
+			return
+		}
+	}
+
+	// the lookup and the email happen after the response, so answering takes as long for unknown emails as for
+	// accounts. Failures are only logged; any other response would tell the caller whether the account exists.
+	ctx := context.WithoutCancel(r.Context())
+	h.background(func() {
+		if err := h.resendActivation(ctx, payload.Email); err != nil {
+			h.logger.Errorf("error resending activation email: %s error: %s", payload.Email, err.Error())
+		}
+	})
+
+	if err := writeResponse(w, http.StatusAccepted, map[string]string{
+		"message": "If an unactivated account with this email exists, a new activation link has been sent",
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// resendActivation replaces the invitation of the unactivated account with the given email and mails the new link.
+// Unknown and already activated emails are silently ignored.
+func (h *Handler) resendActivation(ctx context.Context, email string) error {
+	var user models.User
+	err := h.store.Users.GetUnactivatedByEmail(ctx, email, &user)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	plainToken := uuid.New().String()
+
+	if err := h.store.Users.ReplaceInvitation(ctx, user.ID, hashAndEncodeToken(plainToken), h.mailConfig.Expiry); err != nil {
+		return err
+	}
+
+	return h.sendUserInvitationEmail(user.Username, user.Email, plainToken, !h.isProdEnv)
+}
+
 // CreateUserToken godoc
 //
 //	@Summary		Create a new authentication token for a user
-//	@Description	Generate a new authentication token for a user based on their email and password.
+//	@Description	Generate a short-lived access token and a refresh token for a user based on their email and password.
+//	@Description	Accounts with two-factor authentication get an MFA token instead, to be exchanged with a code at /auth/mfa/login.
+//	@Description	Repeated failures lock the account for a growing period, and each client IP can only try a limited number of times;
+//	@Description	both are answered with 429 and a Retry-After header.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			credentials	body		CreateUserTokenPayload	true	"User credentials"
-//	@Success		200			{object}	map[string]string
+//	@Success		200			{object}	TokenResponse
+//	@Success		202			{object}	MFAChallengeResponse	"Password accepted, complete the login at /auth/mfa/login"
 //	@Failure		400			{object}	error
 //	@Failure		401			{object}	error
+//	@Failure		429			{object}	error
 //	@Failure		500			{object}	error
 //	@Router			/auth/token [post]
 func (h *Handler) CreateUserToken(w http.ResponseWriter, r *http.Request) {
@@ -139,16 +231,28 @@ func (h *Handler) CreateUserToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	attempt := &models.LoginAttempt{
+		Email:     normalizeLoginEmail(payload.Email),
+		IPAddress: clientIP(r),
+	}
+
+	if !h.allowLoginAttempt(w, r, attempt) {
+		return
+	}
+
 	var user models.User
 	err = h.store.Users.GetByEmail(r.Context(), payload.Email, &user)
 
 	// We don't want to reveal whether the email exists or not, so we'll return a generic error message for both cases.
 
 	if err != nil {
-		switch err {
-		case errCustom.ErrResourceNotFound:
-			h.logger.Errorf("user not found: %s", payload.Email)
-			h.badRequestError(w, r, errors.New("invalid email or password"))
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			// Spend as long as a password check would, so the response time doesn't reveal the email is unknown.
+			passwords.Default().VerifyDummy(payload.Password)
+			attempt.FailureReason = models.LoginFailureUnknownEmail
+			h.recordLoginAttempt(r, attempt)
+			h.unauthorizedError(w, r, errInvalidCredentials)
 			return
 		default:
 			h.logger.Errorf("error fetching user by email: %s error: %s", payload.Email, err.Error())
@@ -158,31 +262,268 @@ func (h *Handler) CreateUserToken(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	token, err := h.generateTokenForUser(user.ID)
+	attempt.UserID = &user.ID
+
+	if !user.Password.Check(payload.Password) {
+		attempt.FailureReason = models.LoginFailureInvalidPassword
+		h.recordLoginAttempt(r, attempt)
+		h.unauthorizedError(w, r, errInvalidCredentials)
+		return
+	}
 
+	h.upgradePasswordHash(r, &user, payload.Password)
+
+	required, err := h.requiresSecondFactor(r.Context(), user.ID)
 	if err != nil {
-		// TODO: map the error to a more user-friendly message if needed
-		h.logger.Errorf("error generating JWT token for user: %s error: %s", payload.Email, err.Error())
-		h.internalServerError(w, r, nil)
+		h.internalServerError(w, r, err)
 		return
 	}
 
-	if err := writeResponse(w, http.StatusOK, map[string]string{"token": token}); err != nil {
-		h.logger.Errorf("error writing response for user token generation: %s error: %s", payload.Email, err.Error())
-		h.internalServerError(w, r, nil)
+	if required {
+		attempt.FailureReason = models.LoginSecondFactorRequired
+		h.recordLoginAttempt(r, attempt)
+		h.writeMFAChallenge(w, r, user.ID)
 		return
 	}
+
+	h.completeLogin(w, r, attempt)
 }
 
-// generateTokenForUser generates a JWT token for the given user ID with standard claims.
-func (h *Handler) generateTokenForUser(userID int64) (string, error) {
+// generateTokenForUser generates a JWT token for the given user ID with standard claims. The jti claim
+// identifies the token so it can be revoked before it expires, and the sid claim the session it belongs to.
+func (h *Handler) generateTokenForUser(userID int64, sessionID string) (string, error) {
+
+	now := time.Now()
 
 	claims := jwt.MapClaims{
 		"sub": userID,
-		"aud": h.jwtAuthenticator.Aud,
-		"iss": h.jwtAuthenticator.Iss,
-		"exp": h.jwtAuthenticator.Exp,
+		"aud": h.jwtAuthenticator.Audience(),
+		"iss": h.jwtAuthenticator.Issuer(),
+		"jti": uuid.New().String(),
+		"sid": sessionID,
+		"iat": now.Unix(),
+		"exp": now.Add(h.jwtAuthenticator.TokenExpiry()).Unix(),
 	}
 
 	return h.jwtAuthenticator.GenerateToken(claims)
 }
+
+// RefreshToken godoc
+//
+//	@Summary		Refresh an access token
+//	@Description	Exchange a refresh token for a new access token and refresh token. Each refresh token can only be used once;
+//	@Description	presenting one that was already used revokes every token issued from the same login.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		RefreshTokenPayload	true	"Refresh token"
+//	@Success		200		{object}	TokenResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/refresh [post]
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	refreshToken, next := h.newRefreshToken()
+
+	err := h.store.RefreshTokens.Rotate(r.Context(), hashAndEncodeToken(payload.RefreshToken), next)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRefreshTokenReused):
+			h.logger.Warnf("refresh token reuse detected, token family revoked")
+			h.unauthorizedError(w, r, err)
+			return
+		case errors.Is(err, store.ErrRefreshTokenInvalid):
+			h.unauthorizedError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	// the rotated token stays in the family, so the new access token belongs to the same session
+	accessToken, err := h.generateTokenForUser(next.UserID, next.FamilyID)
+	if err != nil {
+		h.logger.Errorf("error generating JWT token for user: %d error: %s", next.UserID, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, TokenResponse{
+		Token:        accessToken,
+		ExpiresIn:    int64(h.jwtAuthenticator.TokenExpiry().Seconds()),
+		RefreshToken: refreshToken,
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+type LogoutPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"omitempty,max=255"`
+}
+
+// tokenRevocations records revoked access tokens.
+type tokenRevocations interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID int64, revokedBefore, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error)
+}
+
+// revocations returns where access token revocations are kept: Redis when the cache is enabled, Postgres otherwise.
+func (h *Handler) revocations() tokenRevocations {
+	if h.cache.Tokens != nil {
+		return h.cache.Tokens
+	}
+	return h.store.RevokedTokens
+}
+
+// Logout godoc
+//
+//	@Summary		Log out
+//	@Description	Revoke the access token used for this request and end its session. Tokens issued before sessions were tracked
+//	@Description	do not carry one; pass the refresh token of the same login to revoke it as well.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	LogoutPayload	false	"Refresh token to revoke"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, ok := getAccessClaimsFromContext(ctx)
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	var payload LogoutPayload
+	if r.ContentLength != 0 {
+		if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+			return
+		}
+	}
+
+	if err := h.revocations().Revoke(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	err := h.store.Sessions.Revoke(ctx, claims.UserID, claims.SessionID)
+	if err != nil && !errors.Is(err, errCustom.ErrResourceNotFound) {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if payload.RefreshToken != "" {
+		if err := h.store.RefreshTokens.RevokeByToken(ctx, claims.UserID, hashAndEncodeToken(payload.RefreshToken)); err != nil {
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll godoc
+//
+//	@Summary		Log out of all sessions
+//	@Description	Revoke every access and refresh token of the authenticated user, including the one used for this request,
+//	@Description	and every personal access token.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		204	"No Content"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/logout/all [post]
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, ok := getAccessClaimsFromContext(ctx)
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	if err := h.revokeAllTokens(ctx, claims.UserID); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllTokens ends every session of the user: access tokens issued until now and all refresh tokens. Personal
+// access tokens are revoked too, as they would otherwise keep a compromised account reachable.
+func (h *Handler) revokeAllTokens(ctx context.Context, userID int64) error {
+	now := time.Now()
+
+	// every access token issued until now has expired once the access token lifetime has passed
+	if err := h.revocations().RevokeAllForUser(ctx, userID, now, now.Add(h.jwtAuthenticator.TokenExpiry())); err != nil {
+		return err
+	}
+
+	if err := h.store.RefreshTokens.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return h.store.PersonalAccessTokens.DeleteAllForUser(ctx, userID)
+}
+
+// issueTokens starts a new session for the user it was made for and creates its access token and the first
+// refresh token of its token family.
+func (h *Handler) issueTokens(ctx context.Context, session *models.Session) (*TokenResponse, error) {
+	session.ID = uuid.New().String()
+
+	accessToken, err := h.generateTokenForUser(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, stored := h.newRefreshToken()
+
+	if err := h.store.Sessions.Create(ctx, session, stored); err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		Token:        accessToken,
+		ExpiresIn:    int64(h.jwtAuthenticator.TokenExpiry().Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// newRefreshToken generates a random refresh token and the record that stores its hash.
+// The user and family are filled in when it is stored.
+func (h *Handler) newRefreshToken() (string, *models.RefreshToken) {
+	plainToken := generateRandomToken()
+
+	return plainToken, &models.RefreshToken{
+		TokenHash: hashAndEncodeToken(plainToken),
+		ExpiresAt: time.Now().Add(h.jwtConfig.RefreshExpiry),
+	}
+}
+
+// GetJWKS serves the public keys that verify Dusky access tokens as a JSON Web Key Set, so other services can
+// verify tokens without sharing a secret. It lives at /.well-known/jwks.json, outside the versioned API, and
+// is not wrapped in the response envelope.
+func (h *Handler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := writeJSON(w, http.StatusOK, h.jwtAuthenticator.JWKS()); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+// BookmarkPost godoc
+//
+//	@Summary		Bookmark a post
+//	@Description	Save the post to the authenticated user's bookmarks. Bookmarking a post twice has no effect.
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Success		204		{string}	string	""
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/bookmark [put]
+func (h *Handler) BookmarkPost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := getUserFromContext(ctx)
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(ctx, postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.store.Bookmarks.Add(ctx, user.ID, postID); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnbookmarkPost godoc
+//
+//	@Summary		Remove a bookmark
+//	@Description	Remove the post from the authenticated user's bookmarks. Removing a post that is not bookmarked has no effect.
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Success		204		{string}	string	""
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/bookmark [delete]
+func (h *Handler) UnbookmarkPost(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(r.Context(), postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.store.Bookmarks.Remove(r.Context(), user.ID, postID); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserBookmarks godoc
+//
+//	@Summary		Get the authenticated user's bookmarks
+//	@Description	Retrieve a paginated list of the posts the authenticated user has bookmarked, ordered by post creation date rather than bookmark date. Accepts the same pagination and filter parameters as the feed.
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Number of items per page"
+//	@Param			offset	query		int		false	"Number of items to skip (legacy offset mode)"
+//	@Param			cursor	query		string	false	"Opaque cursor from next_cursor or prev_cursor"
+//	@Param			sort	query		string	false	"Sort order by creation date (asc or desc)"
+//	@Param			search	query		string	false	"Only posts matching this full-text search (web search syntax)"
+//	@Param			tags	query		string	false	"Comma separated tags a post must all have (max 4)"
+//	@Param			since	query		string	false	"Only posts created at or after this RFC 3339 timestamp"
+//	@Param			until	query		string	false	"Only posts created at or before this RFC 3339 timestamp"
+//	@Param			authors	query		string	false	"Comma separated IDs of authors to include"
+//	@Success		200		{array}		FeedPostResponse
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks [get]
+func (h *Handler) GetUserBookmarks(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	h.writeFeed(w, r, func(ctx context.Context, query *store.FeedQuery) (*store.FeedPage, error) {
+		return h.store.Bookmarks.GetUserBookmarks(ctx, user.ID, query)
+	})
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+type createCommentPayload struct {
+	Content  string `json:"content" validate:"required,max=1000"`
+	ParentID *int64 `json:"parent_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+type commentPayload struct {
+	Content string `json:"content" validate:"required,max=1000"`
+}
+
+const CommentIDKey string = "commentID"
+
+// getCommentID extracts comment ID from route params.
+func (h *Handler) getCommentID(r *http.Request) (int64, error) {
+	commentID, err := parseIDParam(r, CommentIDKey)
+	if err != nil {
+		return 0, err
+	}
+	return commentID, nil
+}
+
+// CreateComment godoc
+//
+//	@Summary		Create a comment on a post
+//	@Description	Add a new comment to the post identified by its ID. Set parent_id to reply to an existing comment.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64					true	"Post ID"
+//	@Param			comment	body		createCommentPayload	true	"Comment payload"
+//	@Success		201		{object}	models.Comment
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/comments [post]
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := getUserFromContext(ctx)
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	var payload createCommentPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(ctx, postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if payload.ParentID != nil {
+		parent, err := h.store.Comments.GetByID(ctx, *payload.ParentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, errCustom.ErrResourceNotFound):
+				h.badRequestError(w, r, errors.New("parent comment not found"))
+				return
+			default:
+				h.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		if err := validateReplyParent(parent, postID); err != nil {
+			h.badRequestError(w, r, err)
+			return
+		}
+	}
+
+	comment := models.Comment{
+		PostID:   postID,
+		ParentID: payload.ParentID,
+		UserID:   user.ID,
+		Content:  payload.Content,
+		User:     *user,
+	}
+
+	if err := h.store.Comments.Create(ctx, &comment); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusCreated, comment); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// validateReplyParent checks that a new reply can be attached to parent.
+func validateReplyParent(parent *models.Comment, postID int64) error {
+	if parent.PostID != postID {
+		return errors.New("parent comment does not belong to this post")
+	}
+
+	if parent.IsDeleted {
+		return errors.New("cannot reply to a deleted comment")
+	}
+
+	if parent.Depth >= store.MaxCommentDepth {
+		return errors.New("maximum reply depth reached")
+	}
+
+	return nil
+}
+
+// GetPostComments godoc
+//
+//	@Summary		List comments on a post
+//	@Description	Retrieve a paginated list of top-level comments for the post identified by its ID, oldest first. Each comment includes its reply count; use the replies endpoint to load a thread.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			limit	query		int		false	"Number of comments per page"
+//	@Param			offset	query		int		false	"Number of comments to skip"
+//	@Success		200		{array}		models.Comment
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/comments [get]
+func (h *Handler) GetPostComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	query := store.NewPaginatedQuery()
+	if err := query.Parse(r); err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := validatorInstance.Struct(query); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(ctx, postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	comments, err := h.store.Comments.GetRootsByPostID(ctx, postID, query)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, comments); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetCommentReplies godoc
+//
+//	@Summary		List replies to a comment
+//	@Description	Retrieve a paginated list of direct replies to a comment, oldest first. Each reply includes its own reply count.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID		path		int64	true	"Post ID"
+//	@Param			commentID	path		int64	true	"Comment ID"
+//	@Param			limit		query		int		false	"Number of replies per page"
+//	@Param			offset		query		int		false	"Number of replies to skip"
+//	@Success		200			{array}		models.Comment
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/comments/{commentID}/replies [get]
+func (h *Handler) GetCommentReplies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	commentID, err := h.getCommentID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	query := store.NewPaginatedQuery()
+	if err := query.Parse(r); err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := validatorInstance.Struct(query); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	comment, err := h.store.Comments.GetByID(ctx, commentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if comment.PostID != postID {
+		h.notFoundError(w, r, errors.New("comment does not belong to post"))
+		return
+	}
+
+	replies, err := h.store.Comments.GetReplies(ctx, commentID, query)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, replies); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UpdateComment godoc
+//
+//	@Summary		Update a comment
+//	@Description	Update the content of a comment on a post.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID		path		int64			true	"Post ID"
+//	@Param			commentID	path		int64			true	"Comment ID"
+//	@Param			comment		body		commentPayload	true	"Updated comment payload"
+//	@Success		200			{object}	models.Comment
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/comments/{commentID} [patch]
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	commentID, err := h.getCommentID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	var payload commentPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	comment, err := h.store.Comments.GetByID(ctx, commentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	comment.Content = payload.Content
+
+	if err := h.store.Comments.Update(ctx, comment); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := writeResponse(w, http.StatusOK, comment); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeleteComment godoc
+//
+//	@Summary		Delete a comment
+//	@Description	Delete a comment on a post by its ID. A comment that has replies is kept as a tombstone so the thread stays intact.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID		path	int64	true	"Post ID"
+//	@Param			commentID	path	int64	true	"Comment ID"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/comments/{commentID} [delete]
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	commentID, err := h.getCommentID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.Comments.Delete(ctx, commentID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CheckCommentOwnershipMiddleware allows the request through when the authenticated user wrote the comment
+// or their role was granted permission. It also ensures the comment belongs to the post in the URL.
+func (h *Handler) CheckCommentOwnershipMiddleware(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		user, ok := getUserFromContext(r.Context())
+		if !ok {
+			h.internalServerError(w, r, errors.New("user not found in request context"))
+			return
+		}
+
+		postID, err := h.getPostID(r)
+		if err != nil {
+			h.badRequestError(w, r, errors.New("invalid post ID"))
+			return
+		}
+
+		commentID, err := h.getCommentID(r)
+		if err != nil {
+			h.badRequestError(w, r, errors.New("invalid comment ID"))
+			return
+		}
+
+		comment, err := h.store.Comments.GetByID(r.Context(), commentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, errCustom.ErrResourceNotFound):
+				h.notFoundError(w, r, err)
+				return
+			default:
+				h.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		if comment.PostID != postID {
+			h.notFoundError(w, r, errors.New("comment does not belong to post"))
+			return
+		}
+
+		if comment.UserID != user.ID {
+			granted, err := h.hasPermission(r.Context(), user, permission)
+			if err != nil {
+				h.internalServerError(w, r, err)
+				return
+			}
+
+			if !granted {
+				h.forbiddenError(w, r, errors.New("you do not have permission to access this resource"))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type ChangeEmailPayload struct {
+	Email           string `json:"email" validate:"required,email,max=120"`
+	CurrentPassword string `json:"current_password" validate:"required,max=255"`
+}
+
+type ConfirmEmailChangePayload struct {
+	Token string `json:"token" validate:"required,max=255"`
+}
+
+type emailChangeEmailData struct {
+	UserName   string
+	ConfirmURL string
+	ExpiresIn  string
+}
+
+type emailChangeNoticeEmailData struct {
+	UserName string
+	NewEmail string
+}
+
+var errEmailTaken = errors.New("email is already in use")
+
+// ChangeEmail godoc
+//
+//	@Summary		Change the authenticated user's email
+//	@Description	Email a confirmation link to the new address and let the current address know about the request.
+//	@Description	The account keeps its current email until the link is used. Requesting another change replaces the pending one.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ChangeEmailPayload	true	"New email and current password"
+//	@Success		202		{object}	map[string]string
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/email [post]
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	var payload ChangeEmailPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	claims, ok := getAccessClaimsFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	// cached users do not carry the password hash
+	user, ok := h.getStoredUser(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if !h.confirmCurrentPassword(w, r, user, payload.CurrentPassword) {
+		return
+	}
+
+	// emails are case-insensitive in the database
+	if strings.EqualFold(payload.Email, user.Email) {
+		h.badRequestError(w, r, errors.New("the new email is the same as the current one"))
+		return
+	}
+
+	plainToken := generateRandomToken()
+
+	expiry := h.mailConfig.EmailChangeExpiry
+	err := h.store.Users.CreateEmailChange(r.Context(), user.ID, payload.Email, hashAndEncodeToken(plainToken), expiry)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrConflict):
+			h.conflictError(w, r, errEmailTaken)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	confirmData := emailChangeEmailData{
+		UserName:   user.Username,
+		ConfirmURL: h.mailConfig.ApiUrl + "/users/email/confirm?token=" + plainToken,
+		ExpiresIn:  fmt.Sprintf("%d minutes", int(expiry.Minutes())),
+	}
+
+	if err := h.mailer.Send(mailer.TemplateEmailChange, user.Username, payload.Email, confirmData, !h.isProdEnv); err != nil {
+		h.logger.Errorf("failed to send email change confirmation for user: %d error: %s", user.ID, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+
+	// the notice only warns the owner of the current address, so the request does not fail without it
+	noticeData := emailChangeNoticeEmailData{
+		UserName: user.Username,
+		NewEmail: payload.Email,
+	}
+
+	if err := h.mailer.Send(mailer.TemplateEmailChanged, user.Username, user.Email, noticeData, !h.isProdEnv); err != nil {
+		h.logger.Errorf("failed to send email change notice for user: %d error: %s", user.ID, err.Error())
+	}
+
+	if err := writeResponse(w, http.StatusAccepted, map[string]string{
+		"message": "A confirmation link has been sent to the new email",
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// CancelEmailChange godoc
+//
+//	@Summary		Cancel the authenticated user's pending email change
+//	@Description	Discard the pending email change, so its confirmation link stops working. The account keeps its current email.
+//	@Tags			users
+//	@Success		204	"No Content"
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/email [delete]
+func (h *Handler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getAccessClaimsFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	if err := h.store.Users.CancelEmailChange(r.Context(), claims.UserID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, errors.New("no pending email change"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ConfirmEmailChange godoc
+//
+//	@Summary		Confirm an email change
+//	@Description	Switch the account to the new email using the token from the confirmation email. The token can only be used once.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ConfirmEmailChangePayload	true	"Confirmation token"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/users/email/confirm [post]
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var payload ConfirmEmailChangePayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	var user models.User
+	err := h.store.Users.ConfirmEmailChange(r.Context(), hashAndEncodeToken(payload.Token), &user)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.badRequestError(w, r, errors.New("invalid or expired email confirmation token"))
+		case errors.Is(err, errCustom.ErrConflict):
+			h.conflictError(w, r, errEmailTaken)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.invalidateCachedUser(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
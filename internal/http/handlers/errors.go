@@ -28,6 +28,42 @@ func (h *Handler) notFoundError(w http.ResponseWriter, r *http.Request, _ error)
 	writeJSONError(w, http.StatusNotFound, "the requested resource could not be found")
 }
 
+func (h *Handler) conflictError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		err = errors.New("conflict")
+	}
+
+	h.logger.Warnf("conflict error: %s path: %s error: %s", err.Error(), r.URL.Path, r.RemoteAddr)
+	writeJSONError(w, http.StatusConflict, err.Error())
+}
+
+func (h *Handler) preconditionFailedError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		err = errors.New("precondition failed")
+	}
+
+	h.logger.Warnf("precondition failed error: %s path: %s error: %s", err.Error(), r.URL.Path, r.RemoteAddr)
+	writeJSONError(w, http.StatusPreconditionFailed, err.Error())
+}
+
+func (h *Handler) unprocessableEntityError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		err = errors.New("unprocessable entity")
+	}
+
+	h.logger.Warnf("unprocessable entity error: %s path: %s error: %s", err.Error(), r.URL.Path, r.RemoteAddr)
+	writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+}
+
+func (h *Handler) goneError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		err = errors.New("the requested resource is no longer available")
+	}
+
+	h.logger.Warnf("gone error: %s path: %s error: %s", err.Error(), r.URL.Path, r.RemoteAddr)
+	writeJSONError(w, http.StatusGone, err.Error())
+}
+
 func (h *Handler) unauthorizedError(w http.ResponseWriter, r *http.Request, err error) {
 	if err == nil {
 		err = errors.New("unauthorized")
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// postETag formats the entity tag of a post as "<version>". Representations that embed data
+// versioned outside the post, such as its comments, append a ".<digest>" of that data so that
+// If-None-Match still notices when it changes.
+func postETag(version int, digest string) string {
+	if digest == "" {
+		return fmt.Sprintf(`"%d"`, version)
+	}
+	return fmt.Sprintf(`"%d.%s"`, version, digest)
+}
+
+// commentsDigest fingerprints the comments embedded in a post representation.
+func commentsDigest(comments []models.Comment) string {
+	hash := fnv.New64a()
+	for _, comment := range comments {
+		fmt.Fprintf(hash, "%d:%s:%t;", comment.ID, comment.UpdatedAt, comment.IsDeleted)
+	}
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
+// parseIfMatch reads the post version from an If-Match header. It returns ok=false when the header
+// is absent. "*" matches any current version, so it returns ok=true with version 0, which the store
+// treats as an unconditional write.
+func parseIfMatch(header string) (version int, ok bool, err error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false, nil
+	}
+	if header == "*" {
+		return 0, true, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return 0, false, fmt.Errorf("weak entity tags cannot be used with If-Match")
+	}
+
+	if strings.Contains(header, ",") {
+		return 0, false, fmt.Errorf("If-Match must contain a single entity tag")
+	}
+
+	tag := strings.Trim(header, `"`)
+	tag, _, _ = strings.Cut(tag, ".")
+
+	version, err = strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, false, fmt.Errorf("invalid If-Match entity tag %s", header)
+	}
+
+	return version, true, nil
+}
+
+// matchesIfNoneMatch reports whether an If-None-Match header matches etag using the weak comparison
+// that the header calls for.
+func matchesIfNoneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
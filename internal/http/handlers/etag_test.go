@@ -0,0 +1,40 @@
+package handlers
+
+import "testing"
+
+func TestParseIfMatch(t *testing.T) {
+	for header, expected := range map[string]int{`"3"`: 3, `"12.k3j2"`: 12} {
+		version, ok, err := parseIfMatch(header)
+		if err != nil || !ok || version != expected {
+			t.Fatalf("expected %s to give version %d, got %d, %v, %v", header, expected, version, ok, err)
+		}
+	}
+
+	if version, ok, err := parseIfMatch("*"); !ok || version != 0 || err != nil {
+		t.Fatalf("expected * to match any version, got %d, %v, %v", version, ok, err)
+	}
+
+	if _, ok, err := parseIfMatch(""); ok || err != nil {
+		t.Fatalf("expected a missing header to skip the version check")
+	}
+
+	for _, header := range []string{`W/"3"`, `"1", "2"`, `"abc"`, `"0"`} {
+		if _, _, err := parseIfMatch(header); err == nil {
+			t.Fatalf("expected %s to be rejected", header)
+		}
+	}
+}
+
+func TestMatchesIfNoneMatch(t *testing.T) {
+	etag := postETag(4, "abc")
+
+	if !matchesIfNoneMatch(`"1", W/"4.abc"`, etag) {
+		t.Fatalf("expected weak comparison to match %s", etag)
+	}
+	if matchesIfNoneMatch(`"4"`, etag) {
+		t.Fatalf("expected a tag without the comments digest not to match %s", etag)
+	}
+	if !matchesIfNoneMatch("*", etag) {
+		t.Fatalf("expected * to match any representation")
+	}
+}
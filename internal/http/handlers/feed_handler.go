@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/d4rthvadr/dusky-go/internal/store"
@@ -16,37 +18,132 @@ type FeedPostResponse struct {
 	Content      string   `json:"content"`
 	CreatedAt    string   `json:"createdAt"`
 	UpdatedAt    string   `json:"updatedAt"`
+	// ReactionCounts maps each reaction kind to the number of users who reacted with it.
+	ReactionCounts   map[string]int `json:"reactionCounts"`
+	ViewerHasReacted bool           `json:"viewerHasReacted"`
 }
 
+// feedFetcher loads one page of a feed for the parsed query.
+type feedFetcher func(ctx context.Context, query *store.FeedQuery) (*store.FeedPage, error)
+
 // GetUserFeed godoc
 //
 //	@Summary		Get the authenticated user's feed
 //	@Description	Retrieve a paginated list of posts from users that the authenticated user follows.
+//	@Description	Pass the next_cursor or prev_cursor value from a previous response as cursor to page through the feed; offset paging is still supported but cannot be combined with a cursor.
 //	@Tags			Feed
 //	@Accept			json
 //	@Produce		json
-//	@Param			page	query		int	false	"Page number for pagination"
-//	@Param			size	query		int	false	"Number of items per page"
+//	@Param			limit	query		int		false	"Number of items per page"
+//	@Param			offset	query		int		false	"Number of items to skip (legacy offset mode)"
+//	@Param			cursor	query		string	false	"Opaque cursor from next_cursor or prev_cursor"
+//	@Param			sort	query		string	false	"Sort order by creation date (asc or desc)"
+//	@Param			search	query		string	false	"Only posts matching this full-text search (web search syntax)"
+//	@Param			tags	query		string	false	"Comma separated tags a post must all have (max 4)"
+//	@Param			since	query		string	false	"Only posts created at or after this RFC 3339 timestamp"
+//	@Param			until	query		string	false	"Only posts created at or before this RFC 3339 timestamp"
+//	@Param			authors	query		string	false	"Comma separated IDs of authors to include"
 //	@Success		200		{array}		FeedPostResponse
 //	@Failure		400		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/users/feed [get]
 func (h *Handler) GetUserFeed(w http.ResponseWriter, r *http.Request) {
-	query := store.NewPaginatedFeedQuery()
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	h.writeFeed(w, r, func(ctx context.Context, query *store.FeedQuery) (*store.FeedPage, error) {
+		return h.store.Posts.GetUserFeed(ctx, user.ID, query)
+	})
+}
+
+// GetUserPosts godoc
+//
+//	@Summary		Get a user's timeline
+//	@Description	Retrieve a paginated list of posts written by the user identified by its ID. Accepts the same pagination and filter parameters as the feed.
+//	@Tags			Feed
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int64	true	"User ID"
+//	@Param			limit	query		int		false	"Number of items per page"
+//	@Param			offset	query		int		false	"Number of items to skip (legacy offset mode)"
+//	@Param			cursor	query		string	false	"Opaque cursor from next_cursor or prev_cursor"
+//	@Param			sort	query		string	false	"Sort order by creation date (asc or desc)"
+//	@Param			search	query		string	false	"Only posts matching this full-text search (web search syntax)"
+//	@Param			tags	query		string	false	"Comma separated tags a post must all have (max 4)"
+//	@Param			since	query		string	false	"Only posts created at or after this RFC 3339 timestamp"
+//	@Param			until	query		string	false	"Only posts created at or before this RFC 3339 timestamp"
+//	@Success		200		{array}		FeedPostResponse
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/posts [get]
+func (h *Handler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
+	author, ok := getTargetUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("target user not found in request context"))
+		return
+	}
+
+	h.writeFeed(w, r, func(ctx context.Context, query *store.FeedQuery) (*store.FeedPage, error) {
+		return h.store.Posts.GetUserPosts(ctx, author.ID, query)
+	})
+}
+
+// GetExploreFeed godoc
+//
+//	@Summary		Get the explore feed
+//	@Description	Retrieve a paginated list of recent posts from everyone. Accepts the same pagination and filter parameters as the feed.
+//	@Tags			Feed
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Number of items per page"
+//	@Param			offset	query		int		false	"Number of items to skip (legacy offset mode)"
+//	@Param			cursor	query		string	false	"Opaque cursor from next_cursor or prev_cursor"
+//	@Param			sort	query		string	false	"Sort order by creation date (asc or desc)"
+//	@Param			search	query		string	false	"Only posts matching this full-text search (web search syntax)"
+//	@Param			tags	query		string	false	"Comma separated tags a post must all have (max 4)"
+//	@Param			since	query		string	false	"Only posts created at or after this RFC 3339 timestamp"
+//	@Param			until	query		string	false	"Only posts created at or before this RFC 3339 timestamp"
+//	@Param			authors	query		string	false	"Comma separated IDs of authors to include"
+//	@Success		200		{array}		FeedPostResponse
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/explore [get]
+func (h *Handler) GetExploreFeed(w http.ResponseWriter, r *http.Request) {
+	h.writeFeed(w, r, h.store.Posts.GetExploreFeed)
+}
+
+// writeFeed parses and validates the shared feed query, loads a page with fetch and writes it
+// as FeedPostResponse items together with the page cursors. Reaction flags are computed for the
+// authenticated user, if any.
+func (h *Handler) writeFeed(w http.ResponseWriter, r *http.Request, fetch feedFetcher) {
+	query := store.NewFeedQuery()
 	if err := query.Parse(r); err != nil {
 		h.badRequestError(w, r, err)
 		return
 	}
 
-	posts, err := h.store.Posts.GetUserFeed(r.Context(), 1, query)
+	if err := validatorInstance.Struct(query); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if viewer, ok := getUserFromContext(r.Context()); ok {
+		query.ViewerID = viewer.ID
+	}
+
+	page, err := fetch(r.Context(), query)
 	if err != nil {
 		h.internalServerError(w, r, err)
 		return
 	}
 
-	response := make([]FeedPostResponse, 0, len(posts))
-	for _, post := range posts {
+	response := make([]FeedPostResponse, 0, len(page.Posts))
+	for _, post := range page.Posts {
 		response = append(response, FeedPostResponse{
 			ID:           post.ID,
 			Title:        post.Title,
@@ -57,10 +154,13 @@ func (h *Handler) GetUserFeed(w http.ResponseWriter, r *http.Request) {
 			Content:      post.Content,
 			CreatedAt:    post.CreatedAt,
 			UpdatedAt:    post.UpdatedAt,
+
+			ReactionCounts:   post.ReactionCounts,
+			ViewerHasReacted: post.ViewerHasReacted,
 		})
 	}
 
-	if err := writeResponse(w, http.StatusOK, response); err != nil {
+	if err := writePaginatedResponse(w, http.StatusOK, response, page.NextCursor, page.PrevCursor); err != nil {
 		h.internalServerError(w, r, err)
 		return
 	}
@@ -2,26 +2,39 @@ package handlers
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/d4rthvadr/dusky-go/internal/auth"
 	"github.com/d4rthvadr/dusky-go/internal/cache"
 	"github.com/d4rthvadr/dusky-go/internal/config"
 	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/passwords"
 	ratelimiter "github.com/d4rthvadr/dusky-go/internal/ratelmiter"
 	"github.com/d4rthvadr/dusky-go/internal/store"
 	"github.com/d4rthvadr/dusky-go/internal/utils/logger"
 )
 
 type Handler struct {
-	store            store.Storage
-	cache            cache.CacheStorage
-	version          string
-	logger           logger.Logger
-	mailConfig       config.MailConfig
-	mailer           mailer.Client
-	isProdEnv        bool
-	jwtAuthenticator *auth.JWTAuthenticator
-	rateLimiter      ratelimiter.Limiter
+	store                store.Storage
+	cache                cache.CacheStorage
+	version              string
+	logger               logger.Logger
+	mailConfig           config.MailConfig
+	mailer               mailer.Client
+	isProdEnv            bool
+	jwtAuthenticator     auth.Authenticator
+	totpSecretBox        *auth.SecretBox
+	rateLimiter          ratelimiter.Limiter
+	postConfig           config.PostConfig
+	jwtConfig            config.JWTConfig
+	loginConfig          config.LoginConfig
+	loginLimiter         ratelimiter.Limiter
+	magicLinkLimiter     ratelimiter.Limiter
+	passwordResetLimiter ratelimiter.Limiter
+	activationLimiter    ratelimiter.Limiter
+	permissions          *permissionCache
+	passwordPolicy       *passwords.Policy
+	tasks                sync.WaitGroup
 }
 
 type HandlerOptions struct {
@@ -30,22 +43,48 @@ type HandlerOptions struct {
 	Logger           logger.Logger
 	MailConfig       config.MailConfig
 	Mailer           mailer.Client
-	JWTAuthenticator *auth.JWTAuthenticator
+	JWTAuthenticator auth.Authenticator
+	TOTPSecretBox    *auth.SecretBox
 	Cache            cache.CacheStorage
 	IsProdEnv        bool
 	RateLimiter      ratelimiter.Limiter
+	PostConfig       config.PostConfig
+	JWTConfig        config.JWTConfig
+	LoginConfig      config.LoginConfig
+	// LoginLimiter limits login attempts per client IP. Logins are not limited per IP when it is nil.
+	LoginLimiter ratelimiter.Limiter
+	// MagicLinkLimiter limits how often login links are emailed to an address. Sends are not limited when it is nil.
+	MagicLinkLimiter ratelimiter.Limiter
+	// PasswordResetLimiter limits how often password reset links are emailed to an address. Sends are not limited when it is nil.
+	PasswordResetLimiter ratelimiter.Limiter
+	// ActivationLimiter limits how often activation links are resent to an address. Sends are not limited when it is nil.
+	ActivationLimiter ratelimiter.Limiter
+	RoleConfig        config.RoleConfig
+	// PasswordPolicy is enforced when users choose a password. Only the request validation applies when it is nil.
+	PasswordPolicy *passwords.Policy
 }
 
 func New(opts HandlerOptions) *Handler {
 	return &Handler{
-		store:            opts.Store,
-		cache:            opts.Cache,
-		version:          opts.Version,
-		logger:           opts.Logger,
-		mailConfig:       opts.MailConfig,
-		mailer:           opts.Mailer,
-		isProdEnv:        opts.IsProdEnv,
-		jwtAuthenticator: opts.JWTAuthenticator,
+		store:                opts.Store,
+		cache:                opts.Cache,
+		version:              opts.Version,
+		logger:               opts.Logger,
+		mailConfig:           opts.MailConfig,
+		mailer:               opts.Mailer,
+		isProdEnv:            opts.IsProdEnv,
+		jwtAuthenticator:     opts.JWTAuthenticator,
+		totpSecretBox:        opts.TOTPSecretBox,
+		rateLimiter:          opts.RateLimiter,
+		postConfig:           opts.PostConfig,
+		jwtConfig:            opts.JWTConfig,
+		loginConfig:          opts.LoginConfig,
+		loginLimiter:         opts.LoginLimiter,
+		magicLinkLimiter:     opts.MagicLinkLimiter,
+		passwordResetLimiter: opts.PasswordResetLimiter,
+		activationLimiter:    opts.ActivationLimiter,
+		permissions:          newPermissionCache(opts.RoleConfig.PermissionCacheTTL),
+		passwordPolicy:       opts.PasswordPolicy,
 	}
 }
 
@@ -62,3 +101,24 @@ func (h *Handler) ValidateAndParseRequestBody(r *http.Request, w http.ResponseWr
 	}
 	return nil
 }
+
+// background runs fn after the response has been written. A panic in fn is logged instead of crashing the server.
+func (h *Handler) background(fn func()) {
+	h.tasks.Add(1)
+
+	go func() {
+		defer h.tasks.Done()
+		defer func() {
+			if p := recover(); p != nil {
+				h.logger.Errorf("background task panicked: %v", p)
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// Wait blocks until every background task started by the handlers has finished.
+func (h *Handler) Wait() {
+	h.tasks.Wait()
+}
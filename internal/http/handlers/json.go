@@ -15,12 +15,24 @@ func writeJSON(w http.ResponseWriter, status int, data any) error {
 	return json.NewEncoder(w).Encode(data)
 }
 
+// responseEnvelope wraps every successful response. The cursor fields are only filled in for cursor-paginated lists.
+type responseEnvelope struct {
+	Data       any    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
 func writeResponse(w http.ResponseWriter, status int, data any) error {
-	type envelope struct {
-		Data any `json:"data"`
-	}
+	return writeJSON(w, status, &responseEnvelope{Data: data})
+}
 
-	return writeJSON(w, status, &envelope{Data: data})
+// writePaginatedResponse writes data in the response envelope together with the cursors of the neighbouring pages.
+func writePaginatedResponse(w http.ResponseWriter, status int, data any, nextCursor, prevCursor string) error {
+	return writeJSON(w, status, &responseEnvelope{
+		Data:       data,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+	})
 }
 
 var validatorInstance *validator.Validate
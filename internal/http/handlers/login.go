@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/config"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// maxLockoutDoublings bounds the exponent of the progressive lockout so the shift cannot overflow.
+const maxLockoutDoublings = 16
+
+// maxSessionUserAgentLength bounds the user agent stored with a session.
+const maxSessionUserAgentLength = 512
+
+var errInvalidCredentials = errors.New("invalid email or password")
+
+// loginLockout returns how much longer logins to an account stay blocked after its recent failures. Once the
+// failures reach MaxFailedAttempts the account is locked for LockoutDuration after the last failure, and every
+// further failure doubles that, up to MaxLockoutDuration.
+func loginLockout(policy config.LoginConfig, failures *models.LoginFailures, now time.Time) time.Duration {
+	if policy.MaxFailedAttempts <= 0 || failures.Count < policy.MaxFailedAttempts {
+		return 0
+	}
+
+	doublings := min(failures.Count-policy.MaxFailedAttempts, maxLockoutDoublings)
+
+	lockout := policy.LockoutDuration << doublings
+	if policy.MaxLockoutDuration > 0 && lockout > policy.MaxLockoutDuration {
+		lockout = policy.MaxLockoutDuration
+	}
+
+	return max(failures.LastFailedAt.Add(lockout).Sub(now), 0)
+}
+
+// clientIP returns the IP address of the client without the port. RealIP has already replaced RemoteAddr
+// with the forwarded address when the request came through a proxy.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// normalizeLoginEmail is the key failed logins are tracked under, so changing the case of the email does not
+// reset the count.
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// setRetryAfter tells the client how many whole seconds to wait before trying again.
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// allowLoginAttempt enforces the per-IP limit and the account lockout before credentials are checked, and stores
+// the attempt as pending until its outcome is recorded. When the attempt is refused it writes the 429 response and
+// returns false.
+func (h *Handler) allowLoginAttempt(w http.ResponseWriter, r *http.Request, attempt *models.LoginAttempt) bool {
+	if h.loginLimiter != nil {
+		if allowed, retryAfter := h.loginLimiter.Allow(attempt.IPAddress); !allowed {
+			setRetryAfter(w, retryAfter)
+			h.tooManyRequestsError(w, r, errors.New("too many login attempts, please try again later"))
+			return false
+		}
+	}
+
+	failures, err := h.store.LoginAttempts.Begin(r.Context(), attempt, time.Now().Add(-h.loginConfig.FailureWindow))
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return false
+	}
+
+	if wait := loginLockout(h.loginConfig, failures, time.Now()); wait > 0 {
+		attempt.FailureReason = models.LoginFailureLockedOut
+		h.recordLoginAttempt(r, attempt)
+		setRetryAfter(w, wait)
+		h.tooManyRequestsError(w, r, errors.New("account temporarily locked after too many failed logins"))
+		return false
+	}
+
+	return true
+}
+
+// confirmCurrentPassword checks the current password a signed in user gives to change their account. The check
+// counts towards the same lockout as logins, so a stolen access token cannot be used to guess the password. When
+// the password is wrong or the account is locked it writes the response and returns false.
+func (h *Handler) confirmCurrentPassword(w http.ResponseWriter, r *http.Request, user *models.User, plainPassword string) bool {
+	attempt := &models.LoginAttempt{
+		UserID:    &user.ID,
+		Email:     normalizeLoginEmail(user.Email),
+		IPAddress: clientIP(r),
+	}
+
+	if !h.allowLoginAttempt(w, r, attempt) {
+		return false
+	}
+
+	if !user.Password.Check(plainPassword) {
+		attempt.FailureReason = models.LoginFailureInvalidPassword
+		h.recordLoginAttempt(r, attempt)
+		h.forbiddenError(w, r, errors.New("current password is incorrect"))
+		return false
+	}
+
+	attempt.FailureReason = models.LoginPasswordConfirmed
+	h.recordLoginAttempt(r, attempt)
+	return true
+}
+
+// completeLogin issues a new session to the user whose credentials were verified and records the successful attempt.
+func (h *Handler) completeLogin(w http.ResponseWriter, r *http.Request, attempt *models.LoginAttempt) {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxSessionUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxSessionUserAgentLength], "")
+	}
+
+	tokens, err := h.issueTokens(r.Context(), &models.Session{
+		UserID:    *attempt.UserID,
+		UserAgent: userAgent,
+		IPAddress: attempt.IPAddress,
+	})
+	if err != nil {
+		h.logger.Errorf("error generating tokens for user: %s error: %s", attempt.Email, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+
+	attempt.Succeeded = true
+	attempt.FailureReason = ""
+	h.recordLoginAttempt(r, attempt)
+
+	if err := writeResponse(w, http.StatusOK, tokens); err != nil {
+		h.logger.Errorf("error writing response for user token generation: %s error: %s", attempt.Email, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+}
+
+// acceptablePassword enforces the password policy on a password the user is choosing. When the password is
+// refused it writes the 400 response and returns false.
+func (h *Handler) acceptablePassword(w http.ResponseWriter, r *http.Request, plainPassword string) bool {
+	if h.passwordPolicy == nil {
+		return true
+	}
+
+	if err := h.passwordPolicy.Validate(plainPassword); err != nil {
+		h.badRequestError(w, r, err)
+		return false
+	}
+
+	return true
+}
+
+// upgradePasswordHash replaces a password hash made with an outdated algorithm or parameters once the user has
+// logged in with the plain password. Failures are only logged; the old hash keeps working.
+func (h *Handler) upgradePasswordHash(r *http.Request, user *models.User, plainPassword string) {
+	if !user.Password.NeedsRehash() {
+		return
+	}
+
+	oldHash := user.Password.Hash
+	if err := user.Password.Set(plainPassword); err != nil {
+		h.logger.Errorf("error rehashing password for user: %d error: %s", user.ID, err.Error())
+		return
+	}
+
+	if err := h.store.Users.UpdatePasswordHash(r.Context(), user.ID, oldHash, user.Password.Hash); err != nil {
+		h.logger.Warnf("error storing rehashed password for user: %d error: %s", user.ID, err.Error())
+	}
+}
+
+// recordLoginAttempt writes the audit record of a login attempt. It is only logged when that fails, so an
+// unavailable audit table does not block logins.
+func (h *Handler) recordLoginAttempt(r *http.Request, attempt *models.LoginAttempt) {
+	if err := h.store.LoginAttempts.Record(r.Context(), attempt); err != nil {
+		h.logger.Errorf("error recording login attempt for: %s error: %s", attempt.Email, err.Error())
+	}
+}
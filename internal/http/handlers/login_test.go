@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/config"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+func TestLoginLockout(t *testing.T) {
+	policy := config.LoginConfig{
+		MaxFailedAttempts:  3,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: 10 * time.Minute,
+	}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures models.LoginFailures
+		expected time.Duration
+	}{
+		{"below the threshold", models.LoginFailures{Count: 2, LastFailedAt: now}, 0},
+		{"at the threshold", models.LoginFailures{Count: 3, LastFailedAt: now}, time.Minute},
+		{"doubles with every further failure", models.LoginFailures{Count: 5, LastFailedAt: now}, 4 * time.Minute},
+		{"is capped", models.LoginFailures{Count: 40, LastFailedAt: now}, 10 * time.Minute},
+		{"counts from the last failure", models.LoginFailures{Count: 4, LastFailedAt: now.Add(-90 * time.Second)}, 30 * time.Second},
+		{"has expired", models.LoginFailures{Count: 3, LastFailedAt: now.Add(-time.Hour)}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := loginLockout(policy, &tt.failures, now); got != tt.expected {
+			t.Fatalf("%s: expected lockout of %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestLoginLockout_DisabledWithoutThreshold(t *testing.T) {
+	failures := models.LoginFailures{Count: 100, LastFailedAt: time.Now()}
+
+	if got := loginLockout(config.LoginConfig{}, &failures, time.Now()); got != 0 {
+		t.Fatalf("expected no lockout without a configured threshold, got %v", got)
+	}
+}
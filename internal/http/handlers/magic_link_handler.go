@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type SendMagicLinkPayload struct {
+	Email string `json:"email" validate:"required,email,max=120"`
+}
+
+type ConsumeMagicLinkPayload struct {
+	Token string `json:"token" validate:"required,max=255"`
+}
+
+type magicLinkEmailData struct {
+	UserName  string
+	LoginURL  string
+	ExpiresIn string
+}
+
+var errInvalidMagicLink = errors.New("invalid or expired login link")
+
+// SendMagicLink godoc
+//
+//	@Summary		Request a login link
+//	@Description	Email a single-use login link to the account with the given email address, so the user can log in without a password.
+//	@Description	The response is the same whether or not such an account exists. Only a few links are sent to an address per time window.
+//	@Description	The link opens the client login page, which exchanges its token at /auth/magic-link/consume.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		SendMagicLinkPayload	true	"Account email"
+//	@Success		202		{object}	map[string]string
+//	@Failure		400		{object}	error
+//	@Failure		429		{object}	error
+//	@Router			/auth/magic-link [post]
+func (h *Handler) SendMagicLink(w http.ResponseWriter, r *http.Request) {
+	var payload SendMagicLinkPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	// the limit applies to every address, known or not, so hitting it does not reveal whether the account exists
+	if h.magicLinkLimiter != nil {
+		if allowed, retryAfter := h.magicLinkLimiter.Allow(normalizeLoginEmail(payload.Email)); !allowed {
+			setRetryAfter(w, retryAfter)
+			h.tooManyRequestsError(w, r, errors.New("too many login links requested, please try again later"))
+			return
+		}
+	}
+
+	// the lookup and the email happen after the response, so answering takes as long for unknown emails as for
+	// accounts. Failures are only logged; any other response would tell the caller whether the account exists.
+	ctx := context.WithoutCancel(r.Context())
+	h.background(func() {
+		if err := h.sendMagicLink(ctx, payload.Email); err != nil {
+			h.logger.Errorf("error sending login link for: %s error: %s", payload.Email, err.Error())
+		}
+	})
+
+	if err := writeResponse(w, http.StatusAccepted, map[string]string{
+		"message": "If an account with this email exists, a login link has been sent",
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// sendMagicLink creates a login token for the active account with the given email and mails the link to it.
+// Unknown emails are silently ignored.
+func (h *Handler) sendMagicLink(ctx context.Context, email string) error {
+	var user models.User
+	err := h.store.Users.GetByEmail(ctx, email, &user)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	plainToken := generateRandomToken()
+
+	expiry := h.mailConfig.MagicLinkExpiry
+	if err := h.store.Users.CreateMagicLink(ctx, user.ID, hashAndEncodeToken(plainToken), expiry); err != nil {
+		return err
+	}
+
+	emailData := magicLinkEmailData{
+		UserName:  user.Username,
+		LoginURL:  h.mailConfig.MagicLinkURL + "?token=" + plainToken,
+		ExpiresIn: fmt.Sprintf("%d minutes", int(expiry.Minutes())),
+	}
+
+	return h.mailer.Send(mailer.TemplateMagicLink, user.Username, user.Email, emailData, !h.isProdEnv)
+}
+
+// ConsumeMagicLink godoc
+//
+//	@Summary		Log in with a login link
+//	@Description	Exchange the token from a login link email for an access token and a refresh token, like /auth/token does.
+//	@Description	The token can only be used once. Accounts with two-factor authentication get an MFA challenge instead.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ConsumeMagicLinkPayload	true	"Login link token"
+//	@Success		200		{object}	TokenResponse
+//	@Success		202		{object}	MFAChallengeResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/magic-link/consume [post]
+func (h *Handler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var payload ConsumeMagicLinkPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	token := hashAndEncodeToken(payload.Token)
+
+	// Only look the link up here; it is consumed once the lockout check passed, so a locked out user can retry
+	// the same link when the lockout expires.
+	userID, err := h.store.Users.GetMagicLinkUserID(r.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.unauthorizedError(w, r, errInvalidMagicLink)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user, err := h.store.Users.GetByID(r.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.unauthorizedError(w, r, errInvalidMagicLink)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	attempt := &models.LoginAttempt{
+		UserID:    &user.ID,
+		Email:     normalizeLoginEmail(user.Email),
+		IPAddress: clientIP(r),
+	}
+
+	if !h.allowLoginAttempt(w, r, attempt) {
+		return
+	}
+
+	// A parallel request may have consumed the link since it was looked up, in which case this one loses.
+	if _, err := h.store.Users.ConsumeMagicLink(r.Context(), token); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			attempt.FailureReason = models.LoginFailureInvalidMagicLink
+			h.recordLoginAttempt(r, attempt)
+			h.unauthorizedError(w, r, errInvalidMagicLink)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	required, err := h.requiresSecondFactor(r.Context(), user.ID)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if required {
+		attempt.FailureReason = models.LoginSecondFactorRequired
+		h.recordLoginAttempt(r, attempt)
+		h.writeMFAChallenge(w, r, user.ID)
+		return
+	}
+
+	h.completeLogin(w, r, attempt)
+}
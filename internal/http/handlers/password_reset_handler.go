@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/mailer"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email,max=120"`
+}
+
+type ResetPasswordPayload struct {
+	Token           string `json:"token" validate:"required,max=255"`
+	Password        string `json:"password" validate:"required,min=8,max=255"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+}
+
+type passwordResetEmailData struct {
+	UserName  string
+	ResetURL  string
+	ExpiresIn string
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Request a password reset
+//	@Description	Email a single-use password reset link to the account with the given email address.
+//	@Description	The response is the same whether or not such an account exists, so it cannot be used to discover accounts.
+//	@Description	Only a few links are sent to an address per time window.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ForgotPasswordPayload	true	"Account email"
+//	@Success		202		{object}	map[string]string
+//	@Failure		400		{object}	error
+//	@Failure		429		{object}	error
+//	@Router			/auth/password/forgot [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var payload ForgotPasswordPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	// the limit applies to every address, known or not, so hitting it does not reveal whether the account exists
+	if h.passwordResetLimiter != nil {
+		if allowed, retryAfter := h.passwordResetLimiter.Allow(normalizeLoginEmail(payload.Email)); !allowed {
+			setRetryAfter(w, retryAfter)
+			h.tooManyRequestsError(w, r, errors.New("too many password resets requested, please try again later"))
+			return
+		}
+	}
+
+	// the lookup and the email happen after the response, so answering takes as long for unknown emails as for
+	// accounts. Failures are only logged; any other response would tell the caller whether the account exists.
+	ctx := context.WithoutCancel(r.Context())
+	h.background(func() {
+		if err := h.sendPasswordReset(ctx, payload.Email); err != nil {
+			h.logger.Errorf("error sending password reset for: %s error: %s", payload.Email, err.Error())
+		}
+	})
+
+	if err := writeResponse(w, http.StatusAccepted, map[string]string{
+		"message": "If an account with this email exists, a password reset link has been sent",
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// sendPasswordReset creates a reset token for the active account with the given email and mails the link to it.
+// Unknown emails are silently ignored.
+func (h *Handler) sendPasswordReset(ctx context.Context, email string) error {
+	var user models.User
+	err := h.store.Users.GetByEmail(ctx, email, &user)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	plainToken := generateRandomToken()
+
+	expiry := h.mailConfig.PasswordResetExpiry
+	if err := h.store.Users.CreatePasswordReset(ctx, user.ID, hashAndEncodeToken(plainToken), expiry); err != nil {
+		return err
+	}
+
+	emailData := passwordResetEmailData{
+		UserName:  user.Username,
+		ResetURL:  h.mailConfig.ApiUrl + "/auth/password/reset?token=" + plainToken,
+		ExpiresIn: fmt.Sprintf("%d minutes", int(expiry.Minutes())),
+	}
+
+	return h.mailer.Send(mailer.TemplatePasswordReset, user.Username, user.Email, emailData, !h.isProdEnv)
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset a password
+//	@Description	Set a new password using the token from a password reset email. The token can only be used once,
+//	@Description	every existing session of the account is signed out and every personal access token is revoked.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ResetPasswordPayload	true	"Reset token and new password"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/password/reset [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	if !h.acceptablePassword(w, r, payload.Password) {
+		return
+	}
+
+	var user models.User
+	if err := user.Password.Set(payload.Password); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	err := h.store.Users.ResetPassword(r.Context(), hashAndEncodeToken(payload.Token), &user)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.badRequestError(w, r, errors.New("invalid or expired password reset token"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.invalidateCachedUser(r.Context(), user.ID)
+
+	if err := h.revokeAllTokens(r.Context(), user.ID); err != nil {
+		h.logger.Errorf("password reset for user: %d but revoking sessions failed: %s", user.ID, err.Error())
+		h.internalServerError(w, r, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
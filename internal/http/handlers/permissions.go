@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// permissionCache keeps the permissions of each role in memory for ttl, so authorization checks do not hit the
+// database on every request. Changes made through the admin API invalidate the role right away on this instance;
+// other instances pick them up once their entry expires. A ttl of zero disables caching.
+type permissionCache struct {
+	sync.RWMutex
+	ttl   time.Duration
+	roles map[int64]cachedPermissions
+}
+
+type cachedPermissions struct {
+	names     map[string]struct{}
+	expiresAt time.Time
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{
+		ttl:   ttl,
+		roles: make(map[int64]cachedPermissions),
+	}
+}
+
+// get returns the cached permission names of the role, if they have not expired by now.
+func (c *permissionCache) get(roleID int64, now time.Time) (map[string]struct{}, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	entry, ok := c.roles[roleID]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.names, true
+}
+
+// set caches the permissions of the role and returns their names.
+func (c *permissionCache) set(roleID int64, permissions []models.Permission, now time.Time) map[string]struct{} {
+	names := make(map[string]struct{}, len(permissions))
+	for _, permission := range permissions {
+		names[permission.Name] = struct{}{}
+	}
+
+	if c.ttl <= 0 {
+		return names
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.roles[roleID] = cachedPermissions{names: names, expiresAt: now.Add(c.ttl)}
+
+	return names
+}
+
+// invalidate drops the cached permissions of the role.
+func (c *permissionCache) invalidate(roleID int64) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.roles, roleID)
+}
+
+// hasPermission reports whether the user's role was granted permission.
+func (h *Handler) hasPermission(ctx context.Context, user *models.User, permission string) (bool, error) {
+	now := time.Now()
+
+	names, ok := h.permissions.get(user.Role.ID, now)
+	if !ok {
+		permissions, err := h.store.Permissions.GetByRoleID(ctx, user.Role.ID)
+		if err != nil {
+			return false, err
+		}
+		names = h.permissions.set(user.Role.ID, permissions, now)
+	}
+
+	_, granted := names[permission]
+	return granted, nil
+}
+
+// RequirePermission only lets through authenticated users whose role was granted permission.
+func (h *Handler) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := getUserFromContext(r.Context())
+			if !ok {
+				h.internalServerError(w, r, errors.New("user not found in request context"))
+				return
+			}
+
+			granted, err := h.hasPermission(r.Context(), user, permission)
+			if err != nil {
+				h.internalServerError(w, r, err)
+				return
+			}
+
+			if !granted {
+				h.forbiddenError(w, r, errors.New("you do not have permission to access this resource"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+func TestPermissionCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	permissions := []models.Permission{{Name: models.PermissionPostDeleteAny}, {Name: models.PermissionPostPurge}}
+
+	t.Run("serves cached permissions until they expire", func(t *testing.T) {
+		cache := newPermissionCache(time.Minute)
+		cache.set(2, permissions, now)
+
+		names, ok := cache.get(2, now.Add(59*time.Second))
+		if !ok {
+			t.Fatalf("expected a cache hit before the ttl elapsed")
+		}
+		if _, granted := names[models.PermissionPostPurge]; !granted || len(names) != 2 {
+			t.Fatalf("unexpected cached permissions %v", names)
+		}
+
+		if _, ok := cache.get(2, now.Add(time.Minute)); ok {
+			t.Fatalf("expected the entry to expire after the ttl")
+		}
+	})
+
+	t.Run("drops invalidated roles", func(t *testing.T) {
+		cache := newPermissionCache(time.Minute)
+		cache.set(2, permissions, now)
+		cache.set(3, permissions, now)
+
+		cache.invalidate(2)
+
+		if _, ok := cache.get(2, now); ok {
+			t.Fatalf("expected the invalidated role to miss")
+		}
+		if _, ok := cache.get(3, now); !ok {
+			t.Fatalf("expected other roles to stay cached")
+		}
+	})
+
+	t.Run("does not cache without a ttl", func(t *testing.T) {
+		cache := newPermissionCache(0)
+
+		names := cache.set(2, permissions, now)
+		if len(names) != 2 {
+			t.Fatalf("expected set to return the permission names, got %v", names)
+		}
+		if _, ok := cache.get(2, now); ok {
+			t.Fatalf("expected no cache hit when caching is disabled")
+		}
+	})
+}
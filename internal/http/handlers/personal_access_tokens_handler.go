@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type CreatePersonalAccessTokenPayload struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,unique,dive,oneof=posts:read posts:write feed:read"`
+	// ExpiresInDays is how long the token stays valid. Zero means it never expires.
+	ExpiresInDays int `json:"expires_in_days" validate:"gte=0,lte=365"`
+}
+
+// PersonalAccessTokenWithSecret is returned once, when the token is created. The token itself cannot be read again.
+type PersonalAccessTokenWithSecret struct {
+	models.PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// CreatePersonalAccessToken godoc
+//
+//	@Summary		Create a personal access token
+//	@Description	Mint a named token for scripts and integrations. It is sent like a JWT, as "Bearer dsk_pat_...", and can only call
+//	@Description	endpoints that require one of its scopes (posts:read, posts:write, feed:read). The token is only shown in this response.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreatePersonalAccessTokenPayload	true	"Token name, scopes and lifetime"
+//	@Success		201		{object}	PersonalAccessTokenWithSecret
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tokens [post]
+func (h *Handler) CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	var payload CreatePersonalAccessTokenPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	plainToken := models.PersonalAccessTokenPrefix + generateRandomToken()
+
+	token := models.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      payload.Name,
+		TokenHash: hashAndEncodeToken(plainToken),
+		Scopes:    payload.Scopes,
+	}
+
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, payload.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := h.store.PersonalAccessTokens.Create(r.Context(), &token); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrConflict):
+			h.conflictError(w, r, errors.New("a token with this name already exists"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := writeResponse(w, http.StatusCreated, PersonalAccessTokenWithSecret{
+		PersonalAccessToken: token,
+		Token:               plainToken,
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetPersonalAccessTokens godoc
+//
+//	@Summary		List personal access tokens
+//	@Description	List the personal access tokens of the authenticated user with their scopes, expiry and when they were last used.
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{array}		models.PersonalAccessToken
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tokens [get]
+func (h *Handler) GetPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	tokens, err := h.store.PersonalAccessTokens.GetByUserID(r.Context(), user.ID)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, tokens); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeletePersonalAccessToken godoc
+//
+//	@Summary		Revoke a personal access token
+//	@Description	Revoke one of the authenticated user's personal access tokens. It stops working immediately.
+//	@Tags			users
+//	@Produce		json
+//	@Param			tokenID	path	int64	true	"Token ID"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/tokens/{tokenID} [delete]
+func (h *Handler) DeletePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	tokenID, err := parseIDParam(r, "tokenID")
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.PersonalAccessTokens.Delete(r.Context(), user.ID, tokenID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
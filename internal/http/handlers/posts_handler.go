@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/models"
@@ -12,7 +13,12 @@ type createPostPayload struct {
 	Title   string   `json:"title" validate:"required,max=100"`
 	Content string   `json:"content" validate:"required,max=5000"`
 	Tags    []string `json:"tags,omitempty" validate:"dive,required"`
-	Version int      `json:"version" validate:"required"`
+}
+
+type updatePostPayload struct {
+	createPostPayload
+	// Version is the version being edited. It may be omitted when the If-Match header is sent instead.
+	Version int `json:"version" validate:"omitempty,gte=1"`
 }
 
 const PostIDKey string = "postID"
@@ -77,15 +83,19 @@ func (h *Handler) getPostID(r *http.Request) (int64, error) {
 // GetPost godoc
 //
 //	@Summary		Get a post by ID
-//	@Description	Get a post by its ID, including its comments.
+//	@Description	Get a post by its ID, including its comments. The ETag starts with the post version, which If-Match on updates and deletes expects,
+//	@Description	followed by a digest of the comments so that If-None-Match returns 304 only when neither has changed.
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			postID	path		int64	true	"Post ID"
-//	@Success		200		{object}	models.Post
-//	@Failure		400		{object}	error
-//	@Failure		404		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			postID			path		int64	true	"Post ID"
+//	@Param			If-None-Match	header		string	false	"ETag from a previous response"
+//	@Success		200				{object}	models.Post
+//	@Success		304				"Not Modified"
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Header			200				{string}	ETag	"Entity tag of the post"
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{postID} [get]
 func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
@@ -117,6 +127,14 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 
 	post.Comments = comments
 
+	etag := postETag(post.Version, commentsDigest(comments))
+	w.Header().Set("ETag", etag)
+
+	if matchesIfNoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	if err := writeResponse(w, http.StatusOK, post); err != nil {
 		writeJSONError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -126,15 +144,18 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 // DeletePost godoc
 //
 //	@Summary		Delete a post by ID
-//	@Description	Delete a post by its ID.
+//	@Description	Soft-delete a post by its ID. The owner can restore it within the configured grace period until an admin purges it.
+//	@Description	Send If-Match with the post's ETag to only delete the version you have seen.
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			postID	path	int64	true	"Post ID"
-//	@Success		204		"No Content"
-//	@Failure		400		{object}	error
-//	@Failure		404		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			postID		path	int64	true	"Post ID"
+//	@Param			If-Match	header	string	false	"ETag of the version to delete"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		412			{object}	error
+//	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{postID} [delete]
 func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
@@ -146,11 +167,20 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.Posts.Delete(ctx, postID); err != nil {
+	version, _, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.Posts.Delete(ctx, postID, version); err != nil {
 		switch {
 		case errors.Is(err, errCustom.ErrResourceNotFound):
 			h.notFoundError(w, r, err)
 			return
+		case errors.Is(err, errCustom.ErrEditConflict):
+			h.preconditionFailedError(w, r, err)
+			return
 		default:
 			h.internalServerError(w, r, err)
 			return
@@ -163,16 +193,22 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 // UpdatePost godoc
 //
 //	@Summary		Update a post by ID
-//	@Description	Update a post's title, content, and tags by its ID.
+//	@Description	Update a post's title, content, and tags by its ID. The version being edited is taken from the If-Match header
+//	@Description	or, without it, from the version field of the body. A stale If-Match returns 412 and a stale body version returns 409.
+//	@Description	If-Match: * updates whatever version is current.
 //	@Tags			posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			postID	path		int64				true	"Post ID"
-//	@Param			post	body		createPostPayload	true	"Updated post payload"
-//	@Success		200		{object}	models.Post
-//	@Failure		400		{object}	error
-//	@Failure		404		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			postID		path		int64				true	"Post ID"
+//	@Param			If-Match	header		string				false	"ETag of the version being edited"
+//	@Param			post		body		updatePostPayload	true	"Updated post payload"
+//	@Success		200			{object}	models.Post
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		412			{object}	error
+//	@Failure		500			{object}	error
+//	@Header			200			{string}	ETag	"Entity tag of the updated post"
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{postID} [patch]
 func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
@@ -184,7 +220,7 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload createPostPayload
+	var payload updatePostPayload
 	if err := readJSON(r, &payload); err != nil {
 		writeJSONError(w, http.StatusBadRequest, err.Error())
 		return
@@ -195,12 +231,26 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, usesIfMatch, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if !usesIfMatch {
+		if payload.Version == 0 {
+			h.badRequestError(w, r, errors.New("version is required when the If-Match header is not sent"))
+			return
+		}
+		version = payload.Version
+	}
+
 	postModel := models.Post{
 		ID:      postID,
 		Title:   payload.Title,
 		Content: payload.Content,
 		Tags:    payload.Tags,
-		Version: payload.Version,
+		Version: version,
 	}
 
 	if err := h.store.Posts.Update(ctx, &postModel); err != nil {
@@ -208,14 +258,125 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, errCustom.ErrResourceNotFound):
 			h.notFoundError(w, r, err)
 			return
+		case errors.Is(err, errCustom.ErrEditConflict) && usesIfMatch:
+			h.preconditionFailedError(w, r, err)
+			return
+		case errors.Is(err, errCustom.ErrEditConflict):
+			h.conflictError(w, r, err)
+			return
 		default:
 			h.internalServerError(w, r, err)
 			return
 		}
 	}
 
+	w.Header().Set("ETag", postETag(postModel.Version, ""))
+
 	if err := writeResponse(w, http.StatusOK, postModel); err != nil {
 		h.internalServerError(w, r, err)
 		return
 	}
 }
+
+// RestorePost godoc
+//
+//	@Summary		Restore a deleted post
+//	@Description	Undo the deletion of a post. Only the owner can restore a post, and only within the configured grace period after it was deleted.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Success		200		{object}	models.Post
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		410		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/restore [post]
+func (h *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := getUserFromContext(ctx)
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	post, err := h.store.Posts.GetDeletedByID(ctx, postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if post.UserID != user.ID {
+		h.forbiddenError(w, r, errors.New("only the owner can restore a deleted post"))
+		return
+	}
+
+	deletedSince := time.Now().Add(-h.postConfig.RestoreGracePeriod)
+	if err := h.store.Posts.Restore(ctx, postID, deletedSince); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			// the post exists and is deleted, so it fell outside the grace period
+			h.goneError(w, r, errors.New("the restore period for this post has expired"))
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := writeResponse(w, http.StatusOK, post); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// PurgePost godoc
+//
+//	@Summary		Permanently delete a post
+//	@Description	Permanently remove a soft-deleted post together with its comments, reactions, bookmarks and revisions. Requires the post.purge permission.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path	int64	true	"Post ID"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/purge [delete]
+func (h *Handler) PurgePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.Posts.Purge(r.Context(), postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
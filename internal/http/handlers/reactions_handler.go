@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+const ReactionKindKey string = "kind"
+
+// getReactionKind extracts and validates the reaction kind from route params.
+func getReactionKind(r *http.Request) (models.ReactionKind, error) {
+	kind := models.ReactionKind(chi.URLParam(r, ReactionKindKey))
+	if !kind.IsValid() {
+		return "", fmt.Errorf("invalid reaction kind %q", kind)
+	}
+	return kind, nil
+}
+
+// AddPostReaction godoc
+//
+//	@Summary		React to a post
+//	@Description	Add a reaction of the given kind (like, love, laugh or insightful) to the post. Reacting again with the same kind has no effect.
+//	@Tags			reactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			kind	path		string	true	"Reaction kind"
+//	@Success		204		{string}	string	""
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/reactions/{kind} [put]
+func (h *Handler) AddPostReaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := getUserFromContext(ctx)
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	kind, err := getReactionKind(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(ctx, postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.store.Reactions.Add(ctx, postID, user.ID, kind); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemovePostReaction godoc
+//
+//	@Summary		Remove a reaction from a post
+//	@Description	Remove the authenticated user's reaction of the given kind from the post. Removing a reaction that does not exist has no effect.
+//	@Tags			reactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			kind	path		string	true	"Reaction kind"
+//	@Success		204		{string}	string	""
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/reactions/{kind} [delete]
+func (h *Handler) RemovePostReaction(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	kind, err := getReactionKind(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(r.Context(), postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.store.Reactions.Remove(r.Context(), postID, user.ID, kind); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPostReactions godoc
+//
+//	@Summary		List who reacted to a post
+//	@Description	Retrieve a paginated list of users who reacted to the post with the given kind, most recent first.
+//	@Tags			reactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			kind	path		string	true	"Reaction kind"
+//	@Param			limit	query		int		false	"Number of reactions per page"
+//	@Param			offset	query		int		false	"Number of reactions to skip"
+//	@Success		200		{array}		models.Reaction
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/reactions/{kind} [get]
+func (h *Handler) GetPostReactions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	kind, err := getReactionKind(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	query := store.NewPaginatedQuery()
+	if err := query.Parse(r); err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := validatorInstance.Struct(query); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(ctx, postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	reactions, err := h.store.Reactions.GetByPostID(ctx, postID, kind, query)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, reactions); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+	"github.com/d4rthvadr/dusky-go/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const RevisionVersionKey string = "version"
+
+// PostRevisionDiff describes the changes between two versions of a post.
+type PostRevisionDiff struct {
+	PostID      int64            `json:"post_id"`
+	From        int              `json:"from"`
+	To          int              `json:"to"`
+	Title       []utils.DiffLine `json:"title"`
+	Content     []utils.DiffLine `json:"content"`
+	TagsAdded   []string         `json:"tags_added"`
+	TagsRemoved []string         `json:"tags_removed"`
+}
+
+// postRevisionAt returns the post as it was at the given version. The current version is served from the post
+// itself since only superseded versions are archived.
+func (h *Handler) postRevisionAt(ctx context.Context, post *models.Post, version int) (*models.PostRevision, error) {
+	if version == post.Version {
+		return &models.PostRevision{
+			PostID:    post.ID,
+			Version:   post.Version,
+			Title:     post.Title,
+			Content:   post.Content,
+			Tags:      post.Tags,
+			CreatedAt: post.UpdatedAt,
+		}, nil
+	}
+
+	return h.store.Revisions.GetByVersion(ctx, post.ID, version)
+}
+
+// loadPostRevision loads the post at the given version and writes the error response when it cannot.
+func (h *Handler) loadPostRevision(w http.ResponseWriter, r *http.Request, post *models.Post, version int) (*models.PostRevision, bool) {
+	revision, err := h.postRevisionAt(r.Context(), post, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, fmt.Errorf("version %d not found", version))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return revision, true
+}
+
+// parseVersion parses a positive post version.
+func parseVersion(value, name string) (int, error) {
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+	return version, nil
+}
+
+// GetPostRevisions godoc
+//
+//	@Summary		List the revisions of a post
+//	@Description	Retrieve a paginated list of the previous versions of a post, newest first. The current version is the post itself.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			limit	query		int		false	"Number of revisions per page"
+//	@Param			offset	query		int		false	"Number of revisions to skip"
+//	@Success		200		{array}		models.PostRevision
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/revisions [get]
+func (h *Handler) GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	query := store.NewPaginatedQuery()
+	if err := query.Parse(r); err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := validatorInstance.Struct(query); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if _, err := h.store.Posts.GetByID(ctx, postID); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	revisions, err := h.store.Revisions.GetByPostID(ctx, postID, query)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, revisions); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetPostRevisionDiff godoc
+//
+//	@Summary		Diff two versions of a post
+//	@Description	Compare two versions of a post. Title and content are diffed line by line; tags are reported as added or removed.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			from	query		int		true	"Version to compare from"
+//	@Param			to		query		int		true	"Version to compare to"
+//	@Success		200		{object}	PostRevisionDiff
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		422		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/revisions/diff [get]
+func (h *Handler) GetPostRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	qs := r.URL.Query()
+
+	fromVersion, err := parseVersion(qs.Get("from"), "from")
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	toVersion, err := parseVersion(qs.Get("to"), "to")
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	post, err := h.store.Posts.GetByID(ctx, postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	from, ok := h.loadPostRevision(w, r, post, fromVersion)
+	if !ok {
+		return
+	}
+
+	to, ok := h.loadPostRevision(w, r, post, toVersion)
+	if !ok {
+		return
+	}
+
+	titleDiff, err := utils.DiffLines(from.Title, to.Title)
+	if err != nil {
+		h.unprocessableEntityError(w, r, err)
+		return
+	}
+
+	contentDiff, err := utils.DiffLines(from.Content, to.Content)
+	if err != nil {
+		h.unprocessableEntityError(w, r, err)
+		return
+	}
+
+	diff := PostRevisionDiff{
+		PostID:      postID,
+		From:        fromVersion,
+		To:          toVersion,
+		Title:       titleDiff,
+		Content:     contentDiff,
+		TagsAdded:   []string{},
+		TagsRemoved: []string{},
+	}
+
+	for _, tag := range to.Tags {
+		if !slices.Contains(from.Tags, tag) {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+	for _, tag := range from.Tags {
+		if !slices.Contains(to.Tags, tag) {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+
+	if err := writeResponse(w, http.StatusOK, diff); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// RestorePostRevision godoc
+//
+//	@Summary		Restore a previous version of a post
+//	@Description	Make an archived revision the current content of the post. The restore is saved as a new version, so the content it replaces is archived too. Only the owner or a user whose role has the post.update.any permission may restore.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int64	true	"Post ID"
+//	@Param			version	path		int		true	"Version to restore"
+//	@Success		200		{object}	models.Post
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{postID}/revisions/{version}/restore [post]
+func (h *Handler) RestorePostRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, err := h.getPostID(r)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	version, err := parseVersion(chi.URLParam(r, RevisionVersionKey), RevisionVersionKey)
+	if err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	post, err := h.store.Posts.GetByID(ctx, postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if version == post.Version {
+		h.badRequestError(w, r, fmt.Errorf("version %d is already the current version", version))
+		return
+	}
+
+	revision, err := h.store.Revisions.GetByVersion(ctx, postID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, fmt.Errorf("version %d not found", version))
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	post.Title = revision.Title
+	post.Content = revision.Content
+	post.Tags = revision.Tags
+
+	if err := h.store.Posts.Update(ctx, post); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+			return
+		case errors.Is(err, errCustom.ErrEditConflict):
+			h.conflictError(w, r, err)
+			return
+		default:
+			h.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := writeResponse(w, http.StatusOK, post); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
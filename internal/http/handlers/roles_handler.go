@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetRoles godoc
+//
+//	@Summary		List roles
+//	@Description	List every role users can be assigned. Requires the permission.manage permission.
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		models.Role
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles [get]
+func (h *Handler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.store.Roles.List(r.Context())
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, roles); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetPermissions godoc
+//
+//	@Summary		List permissions
+//	@Description	List every permission that can be granted to a role. Requires the permission.manage permission.
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		models.Permission
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/permissions [get]
+func (h *Handler) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.store.Permissions.List(r.Context())
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, permissions); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetRolePermissions godoc
+//
+//	@Summary		List the permissions of a role
+//	@Description	List the permissions granted to the role. Requires the permission.manage permission.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			roleID	path		int64	true	"Role ID"
+//	@Success		200		{array}		models.Permission
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID}/permissions [get]
+func (h *Handler) GetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.getRole(w, r)
+	if !ok {
+		return
+	}
+
+	permissions, err := h.store.Permissions.GetByRoleID(r.Context(), role.ID)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, permissions); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GrantRolePermission godoc
+//
+//	@Summary		Grant a permission to a role
+//	@Description	Grant the named permission to the role. Granting a permission the role already has succeeds without changes.
+//	@Description	Requires the permission.manage permission.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			roleID		path	int64	true	"Role ID"
+//	@Param			permission	path	string	true	"Permission name, e.g. post.delete.any"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID}/permissions/{permission} [put]
+func (h *Handler) GrantRolePermission(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.getRole(w, r)
+	if !ok {
+		return
+	}
+
+	permission := chi.URLParam(r, "permission")
+
+	if err := h.store.Permissions.Grant(r.Context(), role.ID, permission); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, errors.New("permission not found"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.permissions.invalidate(role.ID)
+	h.logger.Infof("permission %s granted to role %s", permission, role.Name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeRolePermission godoc
+//
+//	@Summary		Revoke a permission from a role
+//	@Description	Take the named permission away from the role. permission.manage cannot be revoked from the caller's own role,
+//	@Description	so an administrator cannot lock themselves out. Requires the permission.manage permission.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			roleID		path	int64	true	"Role ID"
+//	@Param			permission	path	string	true	"Permission name, e.g. post.delete.any"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID}/permissions/{permission} [delete]
+func (h *Handler) RevokeRolePermission(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	role, ok := h.getRole(w, r)
+	if !ok {
+		return
+	}
+
+	permission := chi.URLParam(r, "permission")
+
+	if permission == models.PermissionManage && role.ID == user.Role.ID {
+		h.conflictError(w, r, errors.New("cannot revoke permission.manage from your own role"))
+		return
+	}
+
+	if err := h.store.Permissions.Revoke(r.Context(), role.ID, permission); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, errors.New("role does not have this permission"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.permissions.invalidate(role.ID)
+	h.logger.Infof("permission %s revoked from role %s", permission, role.Name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getRole loads the role identified by the {roleID} route param. It writes the error response and returns false
+// when the ID is invalid or the role does not exist.
+func (h *Handler) getRole(w http.ResponseWriter, r *http.Request) (*models.Role, bool) {
+	roleID, err := parseIDParam(r, "roleID")
+	if err != nil {
+		h.badRequestError(w, r, errors.New("invalid role ID"))
+		return nil, false
+	}
+
+	role, err := h.store.Roles.GetByID(r.Context(), roleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return role, true
+}
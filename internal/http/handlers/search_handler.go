@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/d4rthvadr/dusky-go/internal/store"
+)
+
+// Search godoc
+//
+//	@Summary		Search posts or comments
+//	@Description	Full-text search over posts (title, tags and content) or comments, best matches first.
+//	@Description	Words are combined with AND, "quoted phrases" must match in order, a trailing * matches a prefix and a leading - excludes a word.
+//	@Description	Headlines and snippets wrap matches in <mark> tags.
+//	@Tags			search
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string	true	"Search text"
+//	@Param			type	query		string	false	"What to search: posts (default) or comments"
+//	@Param			limit	query		int		false	"Number of results per page"
+//	@Param			offset	query		int		false	"Number of results to skip"
+//	@Success		200		{array}		store.PostSearchResult
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/search [get]
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := store.NewSearchQuery()
+	if err := query.Parse(r); err != nil {
+		h.badRequestError(w, r, err)
+		return
+	}
+
+	if err := validatorInstance.Struct(query); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	var results any
+	var err error
+
+	switch query.Type {
+	case store.SearchTypeComments:
+		results, err = h.store.Search.Comments(r.Context(), query)
+	default:
+		results, err = h.store.Search.Posts(r.Context(), query)
+	}
+
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, results); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GetSessions godoc
+//
+//	@Summary		List sessions
+//	@Description	List the devices the authenticated user is logged in on, with the user agent and IP address of the login
+//	@Description	and when the session was last used. The session of this request is marked as current.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{array}		models.Session
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/sessions [get]
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getAccessClaimsFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	sessions, err := h.store.Sessions.GetByUserID(r.Context(), claims.UserID)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	if err := writeResponse(w, http.StatusOK, sessions); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeleteSession godoc
+//
+//	@Summary		Revoke a session
+//	@Description	Log the authenticated user out of one of their sessions. Its refresh token stops working and its access
+//	@Description	tokens are rejected from the next request on.
+//	@Tags			auth
+//	@Produce		json
+//	@Param			sessionID	path	string	true	"Session ID"
+//	@Success		204			"No Content"
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/sessions/{sessionID} [delete]
+func (h *Handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getAccessClaimsFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("token claims not found in request context"))
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		h.badRequestError(w, r, errors.New("invalid session ID"))
+		return
+	}
+
+	if err := h.store.Sessions.Revoke(r.Context(), claims.UserID, sessionID.String()); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.notFoundError(w, r, err)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 )
 
@@ -11,3 +13,11 @@ func hashAndEncodeToken(token string) string {
 	return hex.EncodeToString(hash[:])
 
 }
+
+// generateRandomToken returns 32 bytes from the system CSPRNG encoded as URL-safe base64.
+func generateRandomToken() string {
+	b := make([]byte, 32)
+	// crypto/rand.Read never returns an error and always fills b
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/auth"
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// totpIssuer is the account issuer shown by authenticator apps.
+	totpIssuer = "Dusky"
+
+	// mfaPendingTokenExpiry is how long a client has to enter the second factor after the password was accepted.
+	mfaPendingTokenExpiry = 5 * time.Minute
+
+	recoveryCodeCount = 10
+)
+
+var errInvalidSecondFactor = errors.New("invalid two-factor code")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TOTPCodePayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// SecondFactorPayload carries either a code from the authenticator app or one of the recovery codes.
+type SecondFactorPayload struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"omitempty,max=32"`
+}
+
+type MFALoginPayload struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	SecondFactorPayload
+}
+
+// RecoveryCodesResponse holds the one-time recovery codes. They are only shown once; the server keeps their hashes.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeResponse is returned instead of tokens when the password of an account with two-factor
+// authentication was accepted. MFAToken must be sent with a code to /auth/mfa/login within ExpiresIn seconds.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// EnrollTOTP godoc
+//
+//	@Summary		Start two-factor enrollment
+//	@Description	Generate a new TOTP secret for the authenticated user. Add it to an authenticator app, for example by
+//	@Description	scanning the otpauth URI as a QR code, then confirm it at /auth/mfa/totp/enable. Starting again replaces a pending secret.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	TOTPEnrollmentResponse
+//	@Failure		401	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/mfa/totp/enroll [post]
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	secret := auth.GenerateTOTPSecret()
+	sealedSecret := h.totpSecretBox.Seal(secret, totpSecretOwner(user.ID))
+
+	if err := h.store.TOTP.Enroll(r.Context(), user.ID, sealedSecret); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrConflict):
+			h.conflictError(w, r, errors.New("two-factor authentication is already enabled"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, TOTPEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(totpIssuer, user.Email, secret),
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// EnableTOTP godoc
+//
+//	@Summary		Enable two-factor authentication
+//	@Description	Confirm the pending TOTP secret with a code from the authenticator app. From then on logins require a second factor.
+//	@Description	The response holds one-time recovery codes that can replace a code once each; they are not shown again.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		TOTPCodePayload	true	"Code from the authenticator app"
+//	@Success		200		{object}	RecoveryCodesResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/mfa/totp/enable [post]
+func (h *Handler) EnableTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	var payload TOTPCodePayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	totp, err := h.store.TOTP.GetByUserID(r.Context(), user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.badRequestError(w, r, errors.New("two-factor enrollment has not been started"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if totp.IsEnabled() {
+		h.conflictError(w, r, errors.New("two-factor authentication is already enabled"))
+		return
+	}
+
+	secret, err := h.totpSecretBox.Open(totp.Secret, totpSecretOwner(totp.UserID))
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(secret, payload.Code, time.Now())
+	if !ok {
+		h.badRequestError(w, r, errInvalidSecondFactor)
+		return
+	}
+
+	codes, hashes := generateRecoveryCodes()
+
+	if err := h.store.TOTP.Enable(r.Context(), user.ID, step, hashes); err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			// enabled by a concurrent request
+			h.conflictError(w, r, errors.New("two-factor authentication is already enabled"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := writeResponse(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DisableTOTP godoc
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Turn off two-factor authentication for the authenticated user. Requires a current code or an unused recovery code.
+//	@Description	Wrong codes count towards the account lockout.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	SecondFactorPayload	true	"Code or recovery code"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/mfa/totp [delete]
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromContext(r.Context())
+	if !ok {
+		h.internalServerError(w, r, errors.New("user not found in request context"))
+		return
+	}
+
+	var payload SecondFactorPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	attempt := &models.LoginAttempt{
+		UserID:    &user.ID,
+		Email:     normalizeLoginEmail(user.Email),
+		IPAddress: clientIP(r),
+	}
+
+	if !h.allowLoginAttempt(w, r, attempt) {
+		return
+	}
+
+	if err := h.verifySecondFactor(r.Context(), user.ID, payload); err != nil {
+		switch {
+		case errors.Is(err, errInvalidSecondFactor):
+			attempt.FailureReason = models.LoginFailureInvalidCode
+			h.recordLoginAttempt(r, attempt)
+			h.badRequestError(w, r, err)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	attempt.FailureReason = models.LoginSecondFactorConfirmed
+	h.recordLoginAttempt(r, attempt)
+
+	if err := h.store.TOTP.Disable(r.Context(), user.ID); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MFALogin godoc
+//
+//	@Summary		Complete a two-factor login
+//	@Description	Exchange the MFA token returned by /auth/token and a code from the authenticator app, or an unused recovery code,
+//	@Description	for an access token and a refresh token. Failed codes count towards the account lockout.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		MFALoginPayload	true	"MFA token and code"
+//	@Success		200		{object}	TokenResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/mfa/login [post]
+func (h *Handler) MFALogin(w http.ResponseWriter, r *http.Request) {
+	var payload MFALoginPayload
+	if err := h.ValidateAndParseRequestBody(r, w, &payload); err != nil {
+		return
+	}
+
+	token, err := h.jwtAuthenticator.ValidateToken(payload.MFAToken)
+	if err != nil {
+		h.unauthorizedError(w, r, errors.New("invalid or expired MFA token"))
+		return
+	}
+
+	userID, err := h.jwtAuthenticator.GetMFAPendingUserID(token)
+	if err != nil {
+		h.unauthorizedError(w, r, err)
+		return
+	}
+
+	user, err := h.store.Users.GetByID(r.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.unauthorizedError(w, r, errors.New("invalid or expired MFA token"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	attempt := &models.LoginAttempt{
+		UserID:    &user.ID,
+		Email:     normalizeLoginEmail(user.Email),
+		IPAddress: clientIP(r),
+	}
+
+	if !h.allowLoginAttempt(w, r, attempt) {
+		return
+	}
+
+	if err := h.verifySecondFactor(r.Context(), user.ID, payload.SecondFactorPayload); err != nil {
+		switch {
+		case errors.Is(err, errInvalidSecondFactor):
+			attempt.FailureReason = models.LoginFailureInvalidCode
+			h.recordLoginAttempt(r, attempt)
+			h.unauthorizedError(w, r, err)
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.completeLogin(w, r, attempt)
+}
+
+// requiresSecondFactor reports whether the user has two-factor authentication enabled.
+func (h *Handler) requiresSecondFactor(ctx context.Context, userID int64) (bool, error) {
+	totp, err := h.store.TOTP.GetByUserID(ctx, userID)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return totp.IsEnabled(), nil
+}
+
+// writeMFAChallenge answers a login whose password was accepted with an MFA pending token instead of a session.
+func (h *Handler) writeMFAChallenge(w http.ResponseWriter, r *http.Request, userID int64) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"sub": userID,
+		"aud": h.jwtAuthenticator.Audience(),
+		"iss": h.jwtAuthenticator.Issuer(),
+		"jti": uuid.New().String(),
+		"iat": now.Unix(),
+		"exp": now.Add(mfaPendingTokenExpiry).Unix(),
+		"typ": auth.TokenTypeMFAPending,
+	}
+
+	mfaToken, err := h.jwtAuthenticator.GenerateToken(claims)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeResponse(w, http.StatusAccepted, MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   int64(mfaPendingTokenExpiry.Seconds()),
+	}); err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+}
+
+// verifySecondFactor checks a TOTP code or recovery code for a user with two-factor authentication enabled and
+// uses it up, so neither can be replayed. Anything that does not verify is reported as errInvalidSecondFactor.
+func (h *Handler) verifySecondFactor(ctx context.Context, userID int64, payload SecondFactorPayload) error {
+	totp, err := h.store.TOTP.GetByUserID(ctx, userID)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return errInvalidSecondFactor
+	}
+	if err != nil {
+		return err
+	}
+
+	if !totp.IsEnabled() {
+		return errInvalidSecondFactor
+	}
+
+	if payload.RecoveryCode != "" {
+		err := h.store.TOTP.UseRecoveryCode(ctx, userID, hashAndEncodeToken(normalizeRecoveryCode(payload.RecoveryCode)))
+		if errors.Is(err, errCustom.ErrResourceNotFound) {
+			return errInvalidSecondFactor
+		}
+		return err
+	}
+
+	secret, err := h.totpSecretBox.Open(totp.Secret, totpSecretOwner(totp.UserID))
+	if err != nil {
+		return err
+	}
+
+	step, ok := auth.ValidateTOTP(secret, payload.Code, time.Now())
+	if !ok {
+		return errInvalidSecondFactor
+	}
+
+	err = h.store.TOTP.UseStep(ctx, userID, step)
+	if errors.Is(err, store.ErrTOTPCodeReused) {
+		return errInvalidSecondFactor
+	}
+	return err
+}
+
+// totpSecretOwner is the additional data a TOTP secret is sealed with, so it only opens for its own user.
+func totpSecretOwner(userID int64) []byte {
+	return []byte("user_totp:" + strconv.FormatInt(userID, 10))
+}
+
+// generateRecoveryCodes returns new recovery codes formatted as xxxx-xxxx, together with the hashes to store.
+func generateRecoveryCodes() (codes []string, hashes []string) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		// crypto/rand.Read never returns an error and always fills b
+		rand.Read(b)
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, hashAndEncodeToken(code))
+	}
+
+	return codes, hashes
+}
+
+// normalizeRecoveryCode accepts recovery codes regardless of case, dashes and spaces.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -3,13 +3,15 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/d4rthvadr/dusky-go/internal/auth"
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/models"
-	"github.com/d4rthvadr/dusky-go/internal/store"
 	"github.com/go-chi/chi/v5"
 	"github.com/redis/go-redis/v9"
 )
@@ -27,7 +29,15 @@ type followUserPayload struct {
 
 type contextKey string
 
+// userContextKey holds the authenticated user; targetUserContextKey holds the user addressed by the {userID} route param.
 const userContextKey contextKey = "user"
+const targetUserContextKey contextKey = "targetUser"
+const accessClaimsContextKey contextKey = "accessClaims"
+const personalAccessTokenContextKey contextKey = "personalAccessToken"
+
+// personalAccessTokenUserContextKey holds the owner of a personal access token until RequireScope has checked the
+// token's scopes and moves it to userContextKey.
+const personalAccessTokenUserContextKey contextKey = "personalAccessTokenUser"
 const UserIDKey string = "userID"
 
 // CreateUser godoc
@@ -60,6 +70,10 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.acceptablePassword(w, r, createUser.Password) {
+		return
+	}
+
 	userModel := models.User{
 		Username: createUser.Username,
 		Email:    createUser.Email,
@@ -96,9 +110,9 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 //	@Router			/users/{userID} [get]
 //	@Security		ApiKeyAuth
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
-	user, ok := getUserFromContext(r.Context())
+	user, ok := getTargetUserFromContext(r.Context())
 	if !ok {
-		h.internalServerError(w, r, errors.New("user not found in request context"))
+		h.internalServerError(w, r, errors.New("target user not found in request context"))
 		return
 	}
 
@@ -135,9 +149,9 @@ func (h *Handler) FollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, ok := getUserFromContext(r.Context())
+	user, ok := getTargetUserFromContext(r.Context())
 	if !ok {
-		h.internalServerError(w, r, errors.New("user not found in request context"))
+		h.internalServerError(w, r, errors.New("target user not found in request context"))
 		return
 	}
 
@@ -171,9 +185,9 @@ func (h *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, ok := getUserFromContext(r.Context())
+	user, ok := getTargetUserFromContext(r.Context())
 	if !ok {
-		h.internalServerError(w, r, errors.New("user not found in request context"))
+		h.internalServerError(w, r, errors.New("target user not found in request context"))
 		return
 	}
 
@@ -236,7 +250,7 @@ func (h *Handler) UserContextMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx := context.WithValue(r.Context(), targetUserContextKey, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -269,6 +283,18 @@ func (h *Handler) getUser(ctx context.Context, userID int64) (*models.User, erro
 
 }
 
+// invalidateCachedUser drops the cached copy of the user, if caching is enabled, so the next read comes from the
+// database. Call it after changing a user's stored data.
+func (h *Handler) invalidateCachedUser(ctx context.Context, userID int64) {
+	if h.cache.Users == nil {
+		return
+	}
+
+	if err := h.cache.Users.Delete(ctx, userID); err != nil {
+		h.logger.Warnf("failed to invalidate cached user: %d error: %s", userID, err.Error())
+	}
+}
+
 func (h *Handler) AuthTokenMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		//
@@ -286,6 +312,11 @@ func (h *Handler) AuthTokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if strings.HasPrefix(tokenStr, models.PersonalAccessTokenPrefix) {
+			h.authenticatePersonalAccessToken(w, r, next, tokenStr)
+			return
+		}
+
 		jwtToken, err := h.jwtAuthenticator.ValidateToken(tokenStr)
 		if err != nil {
 			h.logger.Warnf("invalid token: %s error: %s", tokenStr, err.Error())
@@ -293,26 +324,126 @@ func (h *Handler) AuthTokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		userID, err := h.jwtAuthenticator.GetUserIDFromClaims(jwtToken)
+		claims, err := h.jwtAuthenticator.GetAccessClaims(jwtToken)
 		if err != nil {
-			h.logger.Warnf("failed to get user ID from token claims: %v", err.Error())
+			h.logger.Warnf("failed to get access claims from token: %v", err.Error())
 			h.unauthorizedError(w, r, err)
 			return
 		}
 
-		user, err := h.getUser(r.Context(), userID)
+		revoked, err := h.revocations().IsRevoked(r.Context(), claims.ID, claims.UserID, claims.IssuedAt)
 		if err != nil {
-			h.logger.Warnf("failed to fetch user by ID from token: %d error: %s", userID, err.Error())
+			h.internalServerError(w, r, err)
+			return
+		}
+		if revoked {
+			h.unauthorizedError(w, r, errors.New("token has been revoked"))
+			return
+		}
+
+		// Tokens issued before sessions were tracked carry no sid. They have all expired since, and refreshing
+		// their refresh token issues a token with the sid of its backfilled session, so they are refused.
+		if claims.SessionID == "" {
+			h.unauthorizedError(w, r, errors.New("token has no session, please log in again"))
+			return
+		}
+
+		if err := h.store.Sessions.Touch(r.Context(), claims.SessionID, claims.UserID); err != nil {
+			switch {
+			case errors.Is(err, errCustom.ErrResourceNotFound):
+				h.unauthorizedError(w, r, errors.New("session has ended"))
+			default:
+				h.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		user, err := h.getUser(r.Context(), claims.UserID)
+		if err != nil {
+			h.logger.Warnf("failed to fetch user by ID from token: %d error: %s", claims.UserID, err.Error())
 			h.unauthorizedError(w, r, errors.New("invalid or expired token"))
 			return
 		}
 
 		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, accessClaimsContextKey, claims)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// authenticatePersonalAccessToken is the AuthTokenMiddleware path for personal access tokens. The token is kept in
+// the request context so RequireScope can check its scopes. Its owner only becomes the authenticated user once
+// RequireScope let it through, so routes that declare no scope are closed to personal access tokens.
+func (h *Handler) authenticatePersonalAccessToken(w http.ResponseWriter, r *http.Request, next http.Handler, tokenStr string) {
+	token, err := h.store.PersonalAccessTokens.GetByHash(r.Context(), hashAndEncodeToken(tokenStr))
+	if err != nil {
+		switch {
+		case errors.Is(err, errCustom.ErrResourceNotFound):
+			h.unauthorizedError(w, r, errors.New("invalid or expired token"))
+		default:
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user, err := h.getUser(r.Context(), token.UserID)
+	if err != nil {
+		h.logger.Warnf("failed to fetch user by ID from personal access token: %d error: %s", token.UserID, err.Error())
+		h.unauthorizedError(w, r, errors.New("invalid or expired token"))
+		return
+	}
+
+	if err := h.store.PersonalAccessTokens.Touch(r.Context(), token.ID); err != nil {
+		h.logger.Warnf("failed to record use of personal access token: %d error: %s", token.ID, err.Error())
+	}
+
+	ctx := context.WithValue(r.Context(), personalAccessTokenUserContextKey, user)
+	ctx = context.WithValue(ctx, personalAccessTokenContextKey, token)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireScope only lets through personal access tokens granted scope, and makes the token's owner the
+// authenticated user of the request. Requests authenticated with a session (JWT) are not restricted by scopes.
+func (h *Handler) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := getPersonalAccessTokenFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !token.HasScope(scope) {
+				h.forbiddenError(w, r, fmt.Errorf("token is missing the %s scope", scope))
+				return
+			}
+
+			user, ok := r.Context().Value(personalAccessTokenUserContextKey).(*models.User)
+			if !ok {
+				h.internalServerError(w, r, errors.New("personal access token owner not found in request context"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+		})
+	}
+}
+
+// RequireSessionMiddleware rejects personal access tokens, for endpoints that manage the account itself, such as
+// sessions, two-factor authentication and the personal access tokens themselves.
+func (h *Handler) RequireSessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := getPersonalAccessTokenFromContext(r.Context()); ok {
+			h.forbiddenError(w, r, errors.New("this endpoint cannot be used with a personal access token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // extractTokenFromHeader extracts a JWT token from the Authorization header.
 // It accepts both "Bearer <token>" and raw "<token>" formats.
 func extractTokenFromHeader(authHeader string) string {
@@ -338,23 +469,27 @@ func getUserFromContext(ctx context.Context) (*models.User, bool) {
 	return user, ok
 }
 
-// checkRole checks if the user's role level meets the required role level for accessing a resource.
-func checkRole(ctx context.Context, user *models.User, store store.Storage, requiredRole models.RoleStr) (bool, error) {
-
-	// get role from database
-	roleModel, err := store.Roles.GetByName(ctx, requiredRole)
-	if err != nil {
-		return false, err
-	}
+// getAccessClaimsFromContext returns the claims of the access token validated by AuthTokenMiddleware.
+func getAccessClaimsFromContext(ctx context.Context) (*auth.AccessClaims, bool) {
+	claims, ok := ctx.Value(accessClaimsContextKey).(*auth.AccessClaims)
+	return claims, ok
+}
 
-	if user.Role.Level < roleModel.Level {
-		return false, errors.New("you do not have permission to access this resource")
-	}
+// getPersonalAccessTokenFromContext returns the personal access token the request was authenticated with, if any.
+func getPersonalAccessTokenFromContext(ctx context.Context) (*models.PersonalAccessToken, bool) {
+	token, ok := ctx.Value(personalAccessTokenContextKey).(*models.PersonalAccessToken)
+	return token, ok
+}
 
-	return true, nil
+// getTargetUserFromContext returns the user loaded by UserContextMiddleware from the {userID} route param.
+func getTargetUserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(targetUserContextKey).(*models.User)
+	return user, ok
 }
 
-func (h *Handler) CheckPostOwnershipMiddleware(requiredRole models.RoleStr, next http.HandlerFunc) http.HandlerFunc {
+// CheckPostOwnershipMiddleware allows the request through when the authenticated user wrote the post
+// or their role was granted permission.
+func (h *Handler) CheckPostOwnershipMiddleware(permission string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		user, ok := getUserFromContext(r.Context())
@@ -363,13 +498,6 @@ func (h *Handler) CheckPostOwnershipMiddleware(requiredRole models.RoleStr, next
 			return
 		}
 
-		isAllowed, err := checkRole(r.Context(), user, h.store, requiredRole)
-		if err != nil {
-			h.logger.Errorf("error checking role precedence: %v", err)
-			h.internalServerError(w, r, err)
-			return
-		}
-
 		// get post from database
 		postID, err := parseIDParam(r, "postID")
 		if err != nil {
@@ -383,9 +511,17 @@ func (h *Handler) CheckPostOwnershipMiddleware(requiredRole models.RoleStr, next
 			return
 		}
 
-		if post.UserID != user.ID && !isAllowed {
-			h.forbiddenError(w, r, errors.New("you do not have permission to access this resource"))
-			return
+		if post.UserID != user.ID {
+			granted, err := h.hasPermission(r.Context(), user, permission)
+			if err != nil {
+				h.internalServerError(w, r, err)
+				return
+			}
+
+			if !granted {
+				h.forbiddenError(w, r, errors.New("you do not have permission to access this resource"))
+				return
+			}
 		}
 
 		next.ServeHTTP(w, r)
@@ -403,8 +539,7 @@ func (h *Handler) RateLimitMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ip := r.RemoteAddr
-		allowed, retryAfter := h.rateLimiter.Allow(ip)
+		allowed, retryAfter := h.rateLimiter.Allow(clientIP(r))
 		if !allowed {
 			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
 			h.tooManyRequestsError(w, r, nil)
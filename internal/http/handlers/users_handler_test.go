@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/d4rthvadr/dusky-go/internal/cache"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/d4rthvadr/dusky-go/internal/store"
+	"github.com/d4rthvadr/dusky-go/internal/utils/logger"
+)
+
+func TestAuthTokenMiddleware_PersonalAccessTokensNeedADeclaredScope(t *testing.T) {
+	// the mock store accepts any personal access token as a posts:read token of user 1
+	h := New(HandlerOptions{Store: store.NewMockStore(), Cache: cache.NewMockCache(), Logger: logger.NewLogger()})
+
+	authenticatedUser := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := getUserFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(handler http.Handler) int {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Authorization", "Bearer "+models.PersonalAccessTokenPrefix+"test")
+		recorder := httptest.NewRecorder()
+		h.AuthTokenMiddleware(handler).ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	if code := serve(authenticatedUser); code != http.StatusUnauthorized {
+		t.Fatalf("expected a route without a scope not to see the token's owner, got %d", code)
+	}
+	if code := serve(h.RequireScope(models.ScopePostsRead)(authenticatedUser)); code != http.StatusOK {
+		t.Fatalf("expected a granted scope to authenticate the token's owner, got %d", code)
+	}
+	if code := serve(h.RequireScope(models.ScopePostsWrite)(authenticatedUser)); code != http.StatusForbidden {
+		t.Fatalf("expected a missing scope to be refused, got %d", code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/d4rthvadr/dusky-go/internal/http/handlers"
+	"github.com/d4rthvadr/dusky-go/internal/models"
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -23,43 +24,146 @@ func MountV1Routes(r chi.Router, handler *handlers.Handler, apiURL string) {
 
 		r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
 
+		r.Get("/explore", handler.GetExploreFeed)
+
+		// Personal access tokens can only reach routes that require one of their scopes. Routes that manage the
+		// account itself require a session instead.
+		readPosts := handler.RequireScope(models.ScopePostsRead)
+		writePosts := handler.RequireScope(models.ScopePostsWrite)
+		readFeed := handler.RequireScope(models.ScopeFeedRead)
+
+		r.With(handler.AuthTokenMiddleware, readPosts).Get("/search", handler.Search)
+
 		r.Route("/posts", func(r chi.Router) {
 
 			r.Use(handler.AuthTokenMiddleware)
 
-			r.Post("/", handler.CreatePost)
+			r.With(writePosts).Post("/", handler.CreatePost)
 
 			r.Route("/{postID}", func(r chi.Router) {
-				r.Get("/", handler.GetPost)
-				r.Delete("/", handler.CheckPostOwnershipMiddleware("editor", handler.DeletePost))
-				r.Patch("/", handler.CheckPostOwnershipMiddleware("editor", handler.UpdatePost))
+				r.With(readPosts).Get("/", handler.GetPost)
+				r.With(writePosts).Delete("/", handler.CheckPostOwnershipMiddleware(models.PermissionPostDeleteAny, handler.DeletePost))
+				r.With(writePosts).Patch("/", handler.CheckPostOwnershipMiddleware(models.PermissionPostUpdateAny, handler.UpdatePost))
+				r.With(writePosts).Post("/restore", handler.RestorePost)
+				r.With(writePosts, handler.RequirePermission(models.PermissionPostPurge)).Delete("/purge", handler.PurgePost)
+
+				r.Route("/comments", func(r chi.Router) {
+					r.With(readPosts).Get("/", handler.GetPostComments)
+					r.With(writePosts).Post("/", handler.CreateComment)
+
+					r.Route("/{commentID}", func(r chi.Router) {
+						r.With(readPosts).Get("/replies", handler.GetCommentReplies)
+						r.With(writePosts).Patch("/", handler.CheckCommentOwnershipMiddleware(models.PermissionCommentUpdateAny, handler.UpdateComment))
+						r.With(writePosts).Delete("/", handler.CheckCommentOwnershipMiddleware(models.PermissionCommentDeleteAny, handler.DeleteComment))
+					})
+				})
+
+				r.Route("/reactions/{kind}", func(r chi.Router) {
+					r.With(readPosts).Get("/", handler.GetPostReactions)
+					r.With(writePosts).Put("/", handler.AddPostReaction)
+					r.With(writePosts).Delete("/", handler.RemovePostReaction)
+				})
+
+				r.Route("/revisions", func(r chi.Router) {
+					r.With(readPosts).Get("/", handler.GetPostRevisions)
+					r.With(readPosts).Get("/diff", handler.GetPostRevisionDiff)
+					r.With(writePosts).Post("/{version}/restore", handler.CheckPostOwnershipMiddleware(models.PermissionPostUpdateAny, handler.RestorePostRevision))
+				})
+
+				r.With(writePosts).Put("/bookmark", handler.BookmarkPost)
+				r.With(writePosts).Delete("/bookmark", handler.UnbookmarkPost)
 			})
 		})
 
 		r.Route("/users", func(r chi.Router) {
 
 			r.Put("/activate/{token}", handler.ActivateUserHandler)
+			r.Post("/email/confirm", handler.ConfirmEmailChange)
 
 			r.Group(func(r chi.Router) {
 				r.Use(handler.AuthTokenMiddleware)
 
 				r.Route("/{userID}", func(r chi.Router) {
 					r.Use(handler.UserContextMiddleware)
-					r.Get("/", handler.GetUser)
-					r.Put("/follow", handler.FollowUser)
-					r.Put("/unfollow", handler.UnfollowUser)
+					r.With(handler.RequireSessionMiddleware).Get("/", handler.GetUser)
+					r.With(readFeed).Get("/posts", handler.GetUserPosts)
+					r.With(handler.RequireSessionMiddleware).Put("/follow", handler.FollowUser)
+					r.With(handler.RequireSessionMiddleware).Put("/unfollow", handler.UnfollowUser)
 				})
 
-				r.Get("/feed", handler.GetUserFeed)
+				r.With(readFeed).Get("/feed", handler.GetUserFeed)
+
+				r.Route("/me", func(r chi.Router) {
+					r.With(readFeed).Get("/bookmarks", handler.GetUserBookmarks)
+
+					r.Group(func(r chi.Router) {
+						r.Use(handler.RequireSessionMiddleware)
+						r.Get("/", handler.GetCurrentUser)
+						r.Patch("/", handler.UpdateCurrentUser)
+						r.Post("/password", handler.ChangePassword)
+						r.Post("/email", handler.ChangeEmail)
+						r.Delete("/email", handler.CancelEmailChange)
+					})
+
+					r.Route("/tokens", func(r chi.Router) {
+						r.Use(handler.RequireSessionMiddleware)
+						r.Get("/", handler.GetPersonalAccessTokens)
+						r.Post("/", handler.CreatePersonalAccessToken)
+						r.Delete("/{tokenID}", handler.DeletePersonalAccessToken)
+					})
+				})
 			})
 
 		})
 
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(handler.AuthTokenMiddleware)
+			r.Use(handler.RequireSessionMiddleware)
+			r.Use(handler.RequirePermission(models.PermissionManage))
+
+			r.Get("/roles", handler.GetRoles)
+			r.Get("/permissions", handler.GetPermissions)
+
+			r.Route("/roles/{roleID}/permissions", func(r chi.Router) {
+				r.Get("/", handler.GetRolePermissions)
+				r.Put("/{permission}", handler.GrantRolePermission)
+				r.Delete("/{permission}", handler.RevokeRolePermission)
+			})
+		})
+
 		// Public routes
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/register", handler.RegisterUser)
+			r.Post("/activation/resend", handler.ResendActivation)
 			r.Post("/token", handler.CreateUserToken)
+			r.Post("/refresh", handler.RefreshToken)
+			r.Post("/password/forgot", handler.ForgotPassword)
+			r.Post("/password/reset", handler.ResetPassword)
+			r.Post("/mfa/login", handler.MFALogin)
+			r.Post("/magic-link", handler.SendMagicLink)
+			r.Post("/magic-link/consume", handler.ConsumeMagicLink)
+
+			r.Group(func(r chi.Router) {
+				r.Use(handler.AuthTokenMiddleware)
+				r.Use(handler.RequireSessionMiddleware)
+				r.Post("/logout", handler.Logout)
+				r.Post("/logout/all", handler.LogoutAll)
+
+				r.Get("/sessions", handler.GetSessions)
+				r.Delete("/sessions/{sessionID}", handler.DeleteSession)
+
+				r.Route("/mfa/totp", func(r chi.Router) {
+					r.Post("/enroll", handler.EnrollTOTP)
+					r.Post("/enable", handler.EnableTOTP)
+					r.Delete("/", handler.DisableTOTP)
+				})
+			})
 
 		})
 	})
 }
+
+// MountWellKnownRoutes sets up the unversioned /.well-known endpoints that other services discover by convention.
+func MountWellKnownRoutes(r chi.Router, handler *handlers.Handler) {
+	r.Get("/.well-known/jwks.json", handler.GetJWKS)
+}
@@ -4,6 +4,10 @@ import "embed"
 
 const (
 	TemplateUserInvitation = "user_invitation.tmpl"
+	TemplatePasswordReset  = "password_reset.tmpl"
+	TemplateMagicLink      = "magic_link.tmpl"
+	TemplateEmailChange    = "email_change.tmpl"
+	TemplateEmailChanged   = "email_change_notice.tmpl"
 )
 
 //go:embed templates/*
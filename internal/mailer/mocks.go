@@ -1,7 +1,31 @@
 package mailer
 
-type MockMailer struct{}
+import "sync"
+
+// SentMail is a message the MockMailer was asked to send.
+type SentMail struct {
+	Template string
+	Username string
+	Email    string
+	Data     any
+}
+
+// MockMailer records the messages it is asked to send instead of sending them.
+type MockMailer struct {
+	mu   sync.Mutex
+	sent []SentMail
+}
 
 func (m *MockMailer) Send(templateFile, username, email string, data any, isSandbox bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, SentMail{Template: templateFile, Username: username, Email: email, Data: data})
 	return nil
 }
+
+// Sent returns the messages sent so far, in order.
+func (m *MockMailer) Sent() []SentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SentMail(nil), m.sent...)
+}
@@ -1,10 +1,15 @@
 package models
 
 type Comment struct {
-	ID        int64  `json:"id"`
-	PostID    int64  `json:"post_id"`
-	UserID    int64  `json:"user_id"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"created_at"`
-	User      User   `json:"user"`
+	ID         int64  `json:"id"`
+	PostID     int64  `json:"post_id"`
+	ParentID   *int64 `json:"parent_id"`
+	UserID     int64  `json:"user_id"`
+	Content    string `json:"content"`
+	Depth      int    `json:"depth"`
+	ReplyCount int    `json:"reply_count"`
+	IsDeleted  bool   `json:"is_deleted"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	User       User   `json:"user"`
 }
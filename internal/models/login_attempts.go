@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	LoginFailureUnknownEmail    = "unknown_email"
+	LoginFailureInvalidPassword = "invalid_password"
+	LoginFailureLockedOut       = "locked_out"
+	// LoginFailureInvalidMagicLink is a login link that was used up by another request after it was looked up.
+	LoginFailureInvalidMagicLink = "invalid_magic_link"
+	// LoginFailureInvalidCode is a wrong TOTP or recovery code after the password was accepted.
+	LoginFailureInvalidCode = "invalid_2fa_code"
+	// LoginSecondFactorRequired is an accepted password whose login continues at /auth/mfa/login. It is not
+	// counted as a failure.
+	LoginSecondFactorRequired = "2fa_required"
+	// LoginPasswordConfirmed is a correct current password given by a signed in user to change their account,
+	// which goes through the same lockout as logins. It is neither a failure nor a login.
+	LoginPasswordConfirmed = "password_confirmed"
+	// LoginSecondFactorConfirmed is a correct TOTP or recovery code given by a signed in user to change their
+	// two-factor settings. Like LoginPasswordConfirmed it is neither a failure nor a login.
+	LoginSecondFactorConfirmed = "2fa_confirmed"
+	// LoginAttemptPending marks an attempt whose credentials are still being checked. It counts as a failure
+	// until the outcome is recorded, so parallel guesses cannot all slip under the lockout threshold.
+	LoginAttemptPending = "pending"
+)
+
+// LoginAttempt is the audit record of a single login attempt. UserID is nil when the email matched no account.
+type LoginAttempt struct {
+	ID            int64  `json:"id"`
+	UserID        *int64 `json:"user_id"`
+	Email         string `json:"email"`
+	IPAddress     string `json:"ip_address"`
+	Succeeded     bool   `json:"succeeded"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// LoginFailures summarises the failed logins for an email since its last successful one.
+type LoginFailures struct {
+	Count        int
+	LastFailedAt time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+// Permissions seeded by the migrations. Roles are granted permissions in the role_permissions table;
+// more can be granted or revoked at runtime through the admin API.
+const (
+	PermissionPostUpdateAny    = "post.update.any"
+	PermissionPostDeleteAny    = "post.delete.any"
+	PermissionPostPurge        = "post.purge"
+	PermissionCommentUpdateAny = "comment.update.any"
+	PermissionCommentDeleteAny = "comment.delete.any"
+	PermissionManage           = "permission.manage"
+)
+
+type Permission struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
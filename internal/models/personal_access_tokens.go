@@ -0,0 +1,35 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, so they can be told apart from JWTs
+// and recognised by secret scanners.
+const PersonalAccessTokenPrefix = "dsk_pat_"
+
+// Scopes a personal access token can be granted.
+const (
+	ScopePostsRead  = "posts:read"
+	ScopePostsWrite = "posts:write"
+	ScopeFeedRead   = "feed:read"
+)
+
+// PersonalAccessToken is a named, long-lived token a user mints for scripts and integrations. It can only reach
+// endpoints that require one of its scopes. ExpiresAt is nil for tokens that do not expire.
+type PersonalAccessToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  string     `json:"created_at"`
+}
+
+// HasScope reports whether the token was granted scope.
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
@@ -0,0 +1,28 @@
+package models
+
+type ReactionKind string
+
+const (
+	ReactionLike       ReactionKind = "like"
+	ReactionLove       ReactionKind = "love"
+	ReactionLaugh      ReactionKind = "laugh"
+	ReactionInsightful ReactionKind = "insightful"
+)
+
+// IsValid reports whether k is one of the supported reaction kinds.
+func (k ReactionKind) IsValid() bool {
+	switch k {
+	case ReactionLike, ReactionLove, ReactionLaugh, ReactionInsightful:
+		return true
+	default:
+		return false
+	}
+}
+
+type Reaction struct {
+	PostID    int64        `json:"post_id"`
+	UserID    int64        `json:"user_id"`
+	Kind      ReactionKind `json:"kind"`
+	CreatedAt string       `json:"created_at"`
+	User      User         `json:"user"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RefreshToken is a stored refresh token. Only the hash of the token handed to the client is kept.
+type RefreshToken struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt string    `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// PostRevision is a snapshot of a post's title, content and tags at a given version.
+type PostRevision struct {
+	ID        int64    `json:"id"`
+	PostID    int64    `json:"post_id"`
+	Version   int      `json:"version"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Tags      []string `json:"tags"`
+	CreatedAt string   `json:"created_at"`
+}
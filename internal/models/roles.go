@@ -3,9 +3,9 @@ package models
 type RoleStr string
 
 const (
-	RoleUser  RoleStr = "user"
-	RoleAdmin RoleStr = "admin"
-	RoleMod   RoleStr = "moderator"
+	RoleUser   RoleStr = "user"
+	RoleEditor RoleStr = "editor"
+	RoleAdmin  RoleStr = "admin"
 )
 
 type Role struct {
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is one login of a user and the device it was made from. Its ID is the family ID of the refresh tokens
+// issued by the login. A session ends when its refresh tokens are revoked or expire.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current is set on the session the request listing the sessions was made from.
+	Current bool `json:"current"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// UserTOTP is the TOTP secret of a user. EnabledAt is nil while enrollment has not been confirmed with a code.
+// Secret is stored sealed with auth.SecretBox.
+type UserTOTP struct {
+	UserID       int64      `json:"user_id"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    string     `json:"created_at"`
+}
+
+// IsEnabled reports whether logins of the user require a second factor.
+func (t *UserTOTP) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
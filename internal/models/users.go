@@ -4,18 +4,24 @@ import (
 	"database/sql/driver"
 	"fmt"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/d4rthvadr/dusky-go/internal/passwords"
 )
 
 type User struct {
-	ID        int64    `json:"id"`
-	Username  string   `json:"username"`
-	Email     string   `json:"email"`
-	Password  password `json:"-"`
-	IsActive  bool     `json:"is_active"`
-	Role      Role     `json:"role"`
-	CreatedAt string   `json:"created_at"`
-	UpdatedAt string   `json:"updated_at"`
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// DisplayName, Bio and AvatarURL make up the public profile. They are empty until the user sets them.
+	DisplayName string   `json:"display_name"`
+	Bio         string   `json:"bio"`
+	AvatarURL   string   `json:"avatar_url"`
+	Password    password `json:"-"`
+	IsActive    bool     `json:"is_active"`
+	Role        Role     `json:"role"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+	// LastLoginAt is nil until the user logs in for the first time.
+	LastLoginAt *string `json:"last_login_at"`
 }
 
 type password struct {
@@ -49,10 +55,10 @@ func (p password) Value() (driver.Value, error) {
 	return p.Hash, nil
 }
 
-// Set hashes the plain password and stores it in the password struct
+// Set hashes the plain password with the preferred algorithm and stores it in the password struct
 func (p *password) Set(plainPassword string) error {
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
+	hash, err := passwords.Default().Hash(plainPassword)
 	if err != nil {
 		return err
 	}
@@ -61,8 +67,14 @@ func (p *password) Set(plainPassword string) error {
 	return nil
 }
 
-// Check compares the provided plain password with the stored hash
+// Check compares the provided plain password with the stored hash, whichever algorithm made it
 func (p *password) Check(plainPassword string) bool {
-	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(plainPassword))
-	return err == nil
+	ok, err := passwords.Default().Verify(p.Hash, plainPassword)
+	return err == nil && ok
+}
+
+// NeedsRehash reports whether the stored hash was made with an outdated algorithm or parameters
+// and should be replaced the next time the plain password is known.
+func (p *password) NeedsRehash() bool {
+	return passwords.Default().NeedsRehash(p.Hash)
 }
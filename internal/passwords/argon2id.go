@@ -0,0 +1,137 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2idParams are the cost parameters of Argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the OWASP recommendation of at least 19 MiB of memory, with headroom.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var errMalformedArgon2idHash = errors.New("malformed argon2id hash")
+
+// Validate reports parameters Argon2id cannot hash with. argon2.IDKey panics without at least one iteration
+// and one thread, and needs 8 KiB of memory per thread.
+func (p Argon2idParams) Validate() error {
+	switch {
+	case p.Iterations < 1:
+		return errors.New("argon2id needs at least one iteration")
+	case p.Parallelism < 1:
+		return errors.New("argon2id needs a parallelism of at least one")
+	case p.Memory < 8*uint32(p.Parallelism):
+		return fmt.Errorf("argon2id needs at least %d KiB of memory for a parallelism of %d", 8*uint32(p.Parallelism), p.Parallelism)
+	case p.SaltLength < 8:
+		return errors.New("argon2id needs a salt of at least 8 bytes")
+	case p.KeyLength < 16:
+		return errors.New("argon2id needs a key of at least 16 bytes")
+	default:
+		return nil
+	}
+}
+
+// Argon2id hashes passwords with Argon2id. Hashes use the PHC string format,
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2id struct {
+	params Argon2idParams
+}
+
+func NewArgon2id(params Argon2idParams) *Argon2id {
+	return &Argon2id{params: params}
+}
+
+func (a *Argon2id) Algorithm() string {
+	return AlgorithmArgon2id
+}
+
+func (a *Argon2id) Hash(plainPassword string) ([]byte, error) {
+	salt := make([]byte, a.params.SaltLength)
+	// crypto/rand.Read never returns an error and always fills salt
+	rand.Read(salt)
+
+	key := argon2.IDKey([]byte(plainPassword), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.params.Memory, a.params.Iterations, a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	return []byte(encoded), nil
+}
+
+func (a *Argon2id) Verify(hash []byte, plainPassword string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(plainPassword), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+func (a *Argon2id) NeedsRehash(hash []byte) bool {
+	params, salt, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != a.params.Memory ||
+		params.Iterations != a.params.Iterations ||
+		params.Parallelism != a.params.Parallelism ||
+		params.KeyLength != a.params.KeyLength ||
+		uint32(len(salt)) != a.params.SaltLength
+}
+
+// decodeArgon2id parses a PHC encoded Argon2id hash into its parameters, salt and key.
+func decodeArgon2id(hash []byte) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errMalformedArgon2idHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
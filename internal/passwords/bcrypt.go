@@ -0,0 +1,54 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the cost of bcrypt hashes unless configured otherwise.
+const DefaultBcryptCost = bcrypt.DefaultCost
+
+// Bcrypt hashes passwords with bcrypt. It is kept to verify passwords hashed before Argon2id was introduced.
+type Bcrypt struct {
+	cost int
+}
+
+// ValidateBcryptCost reports costs bcrypt would not hash with. bcrypt silently replaces a cost below
+// bcrypt.MinCost with bcrypt.DefaultCost, which would make every stored hash look outdated.
+func ValidateBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost %d is outside %d..%d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return nil
+}
+
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+func (b *Bcrypt) Algorithm() string {
+	return AlgorithmBcrypt
+}
+
+func (b *Bcrypt) Hash(plainPassword string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(plainPassword), b.cost)
+}
+
+func (b *Bcrypt) Verify(hash []byte, plainPassword string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword(hash, []byte(plainPassword))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (b *Bcrypt) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost != b.cost
+}
@@ -0,0 +1,143 @@
+package passwords
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Algorithm names, as encoded in stored hashes.
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// ErrUnknownAlgorithm is returned when a stored hash was made with an algorithm no hasher is configured for.
+var ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+
+// Hasher hashes and verifies passwords with one algorithm. Its hashes encode the algorithm and its parameters,
+// so hashes made with other parameters still verify and NeedsRehash can tell when they are outdated.
+type Hasher interface {
+	Algorithm() string
+	Hash(plainPassword string) ([]byte, error)
+	// Verify reports whether plainPassword matches hash. It only returns an error for malformed hashes.
+	Verify(hash []byte, plainPassword string) (bool, error)
+	// NeedsRehash reports whether hash was made with parameters other than the hasher's current ones.
+	NeedsRehash(hash []byte) bool
+}
+
+// AlgorithmOf returns the algorithm the stored hash was made with, or "" if it is not recognised.
+func AlgorithmOf(hash []byte) string {
+	switch {
+	case bytes.HasPrefix(hash, []byte("$argon2id$")):
+		return AlgorithmArgon2id
+	case bytes.HasPrefix(hash, []byte("$2a$")), bytes.HasPrefix(hash, []byte("$2b$")), bytes.HasPrefix(hash, []byte("$2y$")):
+		return AlgorithmBcrypt
+	default:
+		return ""
+	}
+}
+
+// Manager hashes new passwords with its preferred hasher and verifies stored hashes with whichever hasher
+// made them, so the algorithm or its parameters can change without invalidating existing passwords.
+type Manager struct {
+	preferred Hasher
+	hashers   map[string]Hasher
+
+	dummyOnce sync.Once
+	dummyHash []byte
+}
+
+// NewManager returns a manager that hashes with preferred and can also verify the hashes of others.
+func NewManager(preferred Hasher, others ...Hasher) *Manager {
+	hashers := map[string]Hasher{preferred.Algorithm(): preferred}
+	for _, hasher := range others {
+		if _, ok := hashers[hasher.Algorithm()]; !ok {
+			hashers[hasher.Algorithm()] = hasher
+		}
+	}
+
+	return &Manager{preferred: preferred, hashers: hashers}
+}
+
+// NewManagerFor returns a manager that prefers the named algorithm and verifies both Argon2id and bcrypt hashes.
+// It rejects parameters either hasher could not hash with, so a misconfiguration fails at startup rather than
+// on the first login.
+func NewManagerFor(algorithm string, argon2idParams Argon2idParams, bcryptCost int) (*Manager, error) {
+	if err := argon2idParams.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateBcryptCost(bcryptCost); err != nil {
+		return nil, err
+	}
+
+	argon2id := NewArgon2id(argon2idParams)
+	bcrypt := NewBcrypt(bcryptCost)
+
+	switch algorithm {
+	case AlgorithmArgon2id:
+		return NewManager(argon2id, bcrypt), nil
+	case AlgorithmBcrypt:
+		return NewManager(bcrypt, argon2id), nil
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", algorithm)
+	}
+}
+
+// Hash hashes plainPassword with the preferred hasher.
+func (m *Manager) Hash(plainPassword string) ([]byte, error) {
+	return m.preferred.Hash(plainPassword)
+}
+
+// Verify reports whether plainPassword matches hash, using the hasher of the algorithm encoded in hash.
+func (m *Manager) Verify(hash []byte, plainPassword string) (bool, error) {
+	hasher, ok := m.hashers[AlgorithmOf(hash)]
+	if !ok {
+		return false, ErrUnknownAlgorithm
+	}
+
+	return hasher.Verify(hash, plainPassword)
+}
+
+// VerifyDummy verifies plainPassword against a hash of a random password, so callers without a stored hash,
+// such as a login for an unknown account, take as long as a real verification. It always reports a mismatch.
+func (m *Manager) VerifyDummy(plainPassword string) {
+	m.dummyOnce.Do(func() {
+		// The hash only has to cost the same to verify, so a failure just leaves it empty
+		m.dummyHash, _ = m.preferred.Hash(rand.Text())
+	})
+	_, _ = m.Verify(m.dummyHash, plainPassword)
+}
+
+// NeedsRehash reports whether hash should be replaced by a hash of the preferred hasher, because it was made
+// with another algorithm or with outdated parameters.
+func (m *Manager) NeedsRehash(hash []byte) bool {
+	if AlgorithmOf(hash) != m.preferred.Algorithm() {
+		return true
+	}
+
+	return m.preferred.NeedsRehash(hash)
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultManager = NewManager(NewArgon2id(DefaultArgon2idParams), NewBcrypt(DefaultBcryptCost))
+)
+
+// Default returns the manager models use to hash and verify passwords.
+func Default() *Manager {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultManager
+}
+
+// SetDefault replaces the manager returned by Default. It is meant to be called once at startup.
+func SetDefault(manager *Manager) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultManager = manager
+}
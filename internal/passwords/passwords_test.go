@@ -0,0 +1,168 @@
+package passwords
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testArgon2idParams keep the tests fast; they are far too cheap for production use.
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id_HashesAndVerifies(t *testing.T) {
+	hasher := NewArgon2id(testArgon2idParams)
+
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if AlgorithmOf(hash) != AlgorithmArgon2id {
+		t.Fatalf("expected the algorithm to be encoded in the hash, got %q", hash)
+	}
+
+	if ok, err := hasher.Verify(hash, "correct horse battery staple"); err != nil || !ok {
+		t.Fatalf("expected the password to verify, got %v, %v", ok, err)
+	}
+	if ok, _ := hasher.Verify(hash, "wrong password"); ok {
+		t.Fatalf("expected a wrong password to be rejected")
+	}
+
+	if hasher.NeedsRehash(hash) {
+		t.Fatalf("expected a hash with the current parameters to be kept")
+	}
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	if !NewArgon2id(stronger).NeedsRehash(hash) {
+		t.Fatalf("expected a hash with outdated parameters to need a rehash")
+	}
+}
+
+func TestArgon2id_RejectsMalformedHashes(t *testing.T) {
+	hasher := NewArgon2id(testArgon2idParams)
+
+	for _, hash := range []string{"$argon2id$", "$argon2id$v=19$m=64,t=1,p=1$!!$!!", "$argon2id$v=1$m=64,t=1,p=1$c2FsdA$a2V5"} {
+		if _, err := hasher.Verify([]byte(hash), "password"); err == nil {
+			t.Fatalf("expected %q to be rejected", hash)
+		}
+	}
+}
+
+func TestManager_VerifiesOlderAlgorithmsAndAsksForRehash(t *testing.T) {
+	bcryptHasher := NewBcrypt(4)
+	legacyHash, err := bcryptHasher.Hash("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(NewArgon2id(testArgon2idParams), bcryptHasher)
+
+	if ok, err := manager.Verify(legacyHash, "password123"); err != nil || !ok {
+		t.Fatalf("expected the bcrypt hash to verify, got %v, %v", ok, err)
+	}
+	if !manager.NeedsRehash(legacyHash) {
+		t.Fatalf("expected a bcrypt hash to be upgraded when Argon2id is preferred")
+	}
+
+	newHash, err := manager.Hash("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if AlgorithmOf(newHash) != AlgorithmArgon2id || manager.NeedsRehash(newHash) {
+		t.Fatalf("expected new hashes to use the preferred algorithm, got %q", newHash)
+	}
+
+	if _, err := manager.Verify([]byte("plaintext"), "plaintext"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Fatalf("expected an unknown hash format to be reported, got %v", err)
+	}
+}
+
+func TestManager_VerifyDummy(t *testing.T) {
+	manager := NewManager(NewArgon2id(testArgon2idParams))
+
+	// Should not panic, and should hash the dummy password once
+	manager.VerifyDummy("correct-horse-battery-staple")
+	manager.VerifyDummy("correct-horse-battery-staple")
+
+	if AlgorithmOf(manager.dummyHash) != AlgorithmArgon2id {
+		t.Fatalf("expected the dummy hash to use the preferred algorithm, got %q", manager.dummyHash)
+	}
+}
+
+func TestNewManagerFor_RejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := NewManagerFor("md5", testArgon2idParams, 4); err == nil {
+		t.Fatalf("expected an unsupported algorithm to be rejected")
+	}
+}
+
+func TestNewManagerFor_RejectsUnusableParameters(t *testing.T) {
+	t.Run("should reject zero argon2id iterations", func(t *testing.T) {
+		params := testArgon2idParams
+		params.Iterations = 0
+		if _, err := NewManagerFor(AlgorithmArgon2id, params, 4); err == nil {
+			t.Fatalf("expected zero iterations to be rejected")
+		}
+	})
+
+	t.Run("should reject zero argon2id parallelism", func(t *testing.T) {
+		params := testArgon2idParams
+		params.Parallelism = 0
+		if _, err := NewManagerFor(AlgorithmArgon2id, params, 4); err == nil {
+			t.Fatalf("expected zero parallelism to be rejected")
+		}
+	})
+
+	t.Run("should reject too little argon2id memory", func(t *testing.T) {
+		params := testArgon2idParams
+		params.Memory = 0
+		if _, err := NewManagerFor(AlgorithmArgon2id, params, 4); err == nil {
+			t.Fatalf("expected zero memory to be rejected")
+		}
+	})
+
+	t.Run("should reject a bcrypt cost outside its range", func(t *testing.T) {
+		for _, cost := range []int{0, 3, 32} {
+			if _, err := NewManagerFor(AlgorithmBcrypt, testArgon2idParams, cost); err == nil {
+				t.Fatalf("expected bcrypt cost %d to be rejected", cost)
+			}
+		}
+	})
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	breachList := "# common passwords\npassword123\n\n" +
+		// SHA-1 of "letmein-please" in the Have I Been Pwned format
+		"b8c7e42d25f47c165216c1b0d35266300d7d219b:12\n"
+	if err := os.WriteFile(path, []byte(breachList), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := NewPolicy(10, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"too short", "short", false},
+		{"counts characters, not bytes", "ääääääääää", true},
+		{"listed in plain text", "password123", false},
+		{"listed by hash", "letmein-please", false},
+		{"acceptable", "a perfectly fine passphrase", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if (err == nil) != tt.valid {
+				t.Fatalf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrBreachedPassword is returned for passwords found in the breach list.
+var ErrBreachedPassword = errors.New("password has appeared in a data breach, please choose another one")
+
+// Policy decides which passwords users may choose. A password must have at least MinLength characters and must
+// not appear in the breach list.
+type Policy struct {
+	MinLength int
+	// breached holds the upper-case hex SHA-1 of every password in the breach list.
+	breached map[string]struct{}
+}
+
+// NewPolicy returns a policy with the given minimum length. When breachListFile is set, the list is loaded
+// into memory; see LoadBreachList for its format.
+func NewPolicy(minLength int, breachListFile string) (*Policy, error) {
+	policy := &Policy{MinLength: minLength, breached: map[string]struct{}{}}
+
+	if breachListFile != "" {
+		if err := policy.LoadBreachList(breachListFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// LoadBreachList adds the passwords in the file to the breach list. Each line is either a plain password or the
+// hex SHA-1 of one, optionally followed by ":<count>" as in the Have I Been Pwned downloads. Empty lines and
+// lines starting with # are skipped.
+func (p *Policy) LoadBreachList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening password breach list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p.breached[breachListKey(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading password breach list: %w", err)
+	}
+
+	return nil
+}
+
+// Validate returns an error describing why plainPassword may not be used, or nil if it may.
+func (p *Policy) Validate(plainPassword string) error {
+	if utf8.RuneCountInString(plainPassword) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+
+	if _, ok := p.breached[sha1Hex(plainPassword)]; ok {
+		return ErrBreachedPassword
+	}
+
+	return nil
+}
+
+// breachListKey returns the SHA-1 a breach list line stands for.
+func breachListKey(line string) string {
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) == sha1.Size*2 {
+		if _, err := hex.DecodeString(hash); err == nil {
+			return strings.ToUpper(hash)
+		}
+	}
+
+	return sha1Hex(line)
+}
+
+func sha1Hex(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
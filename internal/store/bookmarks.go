@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+)
+
+type BookmarkStore struct {
+	db *sql.DB
+}
+
+// Add saves a post to the user's bookmarks. Bookmarking a post twice is a no-op.
+func (s *BookmarkStore) Add(ctx context.Context, userID, postID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	INSERT INTO bookmarks (user_id, post_id)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, post_id) DO NOTHING
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, postID)
+	return errCustom.HandleStorageError(err)
+}
+
+// Remove deletes a post from the user's bookmarks. Removing a post that is not bookmarked is a no-op.
+func (s *BookmarkStore) Remove(ctx context.Context, userID, postID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	DELETE FROM bookmarks
+	WHERE user_id = $1 AND post_id = $2
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, postID)
+	return errCustom.HandleStorageError(err)
+}
+
+// GetUserBookmarks retrieves the posts the user has bookmarked in the feed shape, ordered and paginated like the feed.
+// Posts are ordered by when they were created, not when they were bookmarked, so the feed's cursors, sort and date
+// filters apply unchanged.
+func (s *BookmarkStore) GetUserBookmarks(ctx context.Context, userID int64, feedQuery *FeedQuery) (*FeedPage, error) {
+	args := sqlArgs{}
+
+	return queryFeed(ctx, s.db, feedScope{
+		join:  "join bookmarks b on b.post_id = p.id and b.user_id = " + args.add(userID),
+		where: "TRUE",
+	}, &args, feedQuery)
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBookmarkStore(t *testing.T) {
+
+	t.Run("should ignore bookmarking a post twice", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: "ON CONFLICT (user_id, post_id) DO NOTHING"})
+
+		if err := (&BookmarkStore{db: db}).Add(context.Background(), 1, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+	})
+
+	t.Run("should list bookmarks by post creation date", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: "join bookmarks b on b.post_id = p.id and b.user_id = $1"})
+
+		if _, err := (&BookmarkStore{db: db}).GetUserBookmarks(context.Background(), 1, NewFeedQuery()); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+		if !fake.ran("ORDER BY p.created_at") {
+			t.Fatalf("expected bookmarks to be ordered by post creation date, ran %q", fake.executed)
+		}
+	})
+
+}
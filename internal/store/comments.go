@@ -4,51 +4,207 @@ import (
 	"context"
 	"database/sql"
 
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/models"
 )
 
+// MaxCommentDepth is the deepest nesting level a reply can be created at. Root comments have depth 0.
+const MaxCommentDepth = 4
+
 type CommentStore struct {
 	db *sql.DB
 }
 
-func (c *CommentStore) GetByPostID(ctx context.Context, postID int64) ([]models.Comment, error) {
-	query := `
-	SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, users.username
-	FROM comments c left join users on c.user_id = users.id
-	WHERE c.post_id = $1
-	ORDER BY c.created_at ASC
-	`
+// commentColumns is the select list shared by every comment query, scanned by scanComment.
+const commentColumns = `
+	c.id, c.post_id, c.parent_id, c.user_id, c.content, c.depth,
+	(SELECT count(*) FROM comments r WHERE r.parent_id = c.id) AS reply_count,
+	c.deleted_at IS NOT NULL AS is_deleted,
+	c.created_at, c.updated_at, COALESCE(users.username, '')
+`
+
+// commentOfLivePost restricts a query on comments c to comments of posts that are not soft-deleted.
+const commentOfLivePost = `EXISTS (SELECT 1 FROM posts p WHERE p.id = c.post_id AND p.deleted_at IS NULL)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row rowScanner, comment *models.Comment) error {
+	return row.Scan(
+		&comment.ID, &comment.PostID, &comment.ParentID, &comment.UserID, &comment.Content, &comment.Depth,
+		&comment.ReplyCount, &comment.IsDeleted, &comment.CreatedAt, &comment.UpdatedAt, &comment.User.Username,
+	)
+}
+
+// maskDeleted hides the author and content of a tombstoned comment so it only holds its place in the thread.
+func maskDeleted(comment *models.Comment) {
+	if !comment.IsDeleted {
+		return
+	}
+	comment.Content = ""
+	comment.UserID = 0
+	comment.User = models.User{}
+}
 
-	rows, err := c.db.QueryContext(ctx, query, postID)
+func (c *CommentStore) queryComments(ctx context.Context, query string, args ...any) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, errCustom.HandleStorageError(err)
 	}
 	defer rows.Close()
 
-	var comments []models.Comment
+	comments := []models.Comment{}
 
 	for rows.Next() {
 		var comment models.Comment
-		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Content, &comment.CreatedAt, &comment.User.Username); err != nil {
-			return nil, err
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, errCustom.HandleStorageError(err)
 		}
+		maskDeleted(&comment)
 		comments = append(comments, comment)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, errCustom.HandleStorageError(err)
 	}
 
 	return comments, nil
 }
 
+// GetByPostID retrieves every comment of a post as a flat list ordered by creation time.
+// Replies carry their parent_id so clients can rebuild the thread.
+func (c *CommentStore) GetByPostID(ctx context.Context, postID int64) ([]models.Comment, error) {
+	query := `
+	SELECT ` + commentColumns + `
+	FROM comments c left join users on c.user_id = users.id
+	WHERE c.post_id = $1 AND ` + commentOfLivePost + `
+	ORDER BY c.created_at ASC
+	`
+
+	return c.queryComments(ctx, query, postID)
+}
+
+// GetRootsByPostID retrieves a page of top-level comments for a post together with their reply counts.
+func (c *CommentStore) GetRootsByPostID(ctx context.Context, postID int64, paginatedQuery *PaginatedQuery) ([]models.Comment, error) {
+	query := `
+	SELECT ` + commentColumns + `
+	FROM comments c left join users on c.user_id = users.id
+	WHERE c.post_id = $1 AND c.parent_id IS NULL AND ` + commentOfLivePost + `
+	ORDER BY c.created_at ASC, c.id ASC
+	LIMIT $2 OFFSET $3
+	`
+
+	return c.queryComments(ctx, query, postID, paginatedQuery.Limit, paginatedQuery.Offset)
+}
+
+// GetReplies retrieves a page of direct replies to a comment together with their own reply counts.
+func (c *CommentStore) GetReplies(ctx context.Context, parentID int64, paginatedQuery *PaginatedQuery) ([]models.Comment, error) {
+	query := `
+	SELECT ` + commentColumns + `
+	FROM comments c left join users on c.user_id = users.id
+	WHERE c.parent_id = $1 AND ` + commentOfLivePost + `
+	ORDER BY c.created_at ASC, c.id ASC
+	LIMIT $2 OFFSET $3
+	`
+
+	return c.queryComments(ctx, query, parentID, paginatedQuery.Limit, paginatedQuery.Offset)
+}
+
+// GetByID retrieves a single comment by its ID, including tombstoned comments.
+// Comments of soft-deleted posts are not found.
+func (c *CommentStore) GetByID(ctx context.Context, id int64) (*models.Comment, error) {
+	query := `
+	SELECT ` + commentColumns + `
+	FROM comments c left join users on c.user_id = users.id
+	WHERE c.id = $1 AND ` + commentOfLivePost + `
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var comment models.Comment
+	if err := scanComment(c.db.QueryRowContext(ctx, query, id), &comment); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return &comment, nil
+}
+
+// Create inserts a new comment. When the comment is a reply its depth is derived from the parent.
 func (c *CommentStore) Create(ctx context.Context, comment *models.Comment) error {
 	query := `
-	INSERT INTO comments (post_id, user_id, content) 
-	VALUES ($1, $2, $3) RETURNING id, created_at
+	INSERT INTO comments (post_id, user_id, content, parent_id, depth)
+	VALUES ($1, $2, $3, $4, COALESCE((SELECT depth + 1 FROM comments WHERE id = $4), 0))
+	RETURNING id, depth, created_at, updated_at
 	`
-	err := c.db.QueryRowContext(ctx, query, comment.PostID, comment.UserID, comment.Content).
-		Scan(&comment.ID, &comment.CreatedAt)
+	err := c.db.QueryRowContext(ctx, query, comment.PostID, comment.UserID, comment.Content, comment.ParentID).
+		Scan(&comment.ID, &comment.Depth, &comment.CreatedAt, &comment.UpdatedAt)
 
 	return err
 }
+
+// Update changes the content of an existing comment and refreshes its updated_at timestamp.
+// Tombstoned comments cannot be edited.
+func (c *CommentStore) Update(ctx context.Context, comment *models.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	UPDATE comments c
+	SET content = $1
+	WHERE c.id = $2 AND c.deleted_at IS NULL AND ` + commentOfLivePost + `
+	RETURNING updated_at
+	`
+	err := c.db.QueryRowContext(ctx, query, comment.Content, comment.ID).
+		Scan(&comment.UpdatedAt)
+
+	return errCustom.HandleStorageError(err)
+}
+
+// Delete removes a comment by its ID. A comment that still has replies is kept as a tombstone
+// so the thread below it stays intact; a comment without replies is removed outright.
+func (c *CommentStore) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, c.db, func(tx *sql.Tx) error {
+
+		// Inserting a reply takes a key share lock on its parent through the foreign key, so holding the row lock
+		// until the transaction ends keeps a reply from arriving between the check below and the delete, where the
+		// cascade would remove it.
+		query := `
+		SELECT c.deleted_at IS NOT NULL
+		FROM comments c
+		WHERE c.id = $1 AND ` + commentOfLivePost + `
+		FOR UPDATE OF c
+		`
+
+		var isDeleted bool
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&isDeleted); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		var hasReplies bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM comments WHERE parent_id = $1)`, id).
+			Scan(&hasReplies)
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		if !hasReplies {
+			_, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id)
+			return errCustom.HandleStorageError(err)
+		}
+
+		if isDeleted {
+			return errCustom.ErrResourceNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE comments SET content = '', deleted_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+		return errCustom.HandleStorageError(err)
+	})
+}
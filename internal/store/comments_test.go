@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// lockComment is the statement Delete starts with, answered with whether the comment is a tombstone.
+func lockComment(isDeleted bool) fakeStatement {
+	return fakeStatement{match: "FOR UPDATE OF c", columns: []string{"deleted"}, rows: [][]driver.Value{{isDeleted}}}
+}
+
+func hasReplies(exists bool) fakeStatement {
+	return fakeStatement{match: "WHERE parent_id = $1", columns: []string{"exists"}, rows: [][]driver.Value{{exists}}}
+}
+
+func TestCommentStore_Delete(t *testing.T) {
+
+	t.Run("should remove a comment without replies", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			lockComment(false),
+			hasReplies(false),
+			fakeStatement{match: "DELETE FROM comments WHERE id = $1", rowsAffected: 1},
+		)
+
+		if err := (&CommentStore{db: db}).Delete(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+		if fake.ran("UPDATE comments") || !fake.ran("COMMIT") {
+			t.Fatalf("expected the comment to be removed, ran %q", fake.executed)
+		}
+	})
+
+	t.Run("should keep a comment with replies as a tombstone", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			lockComment(false),
+			hasReplies(true),
+			fakeStatement{match: "UPDATE comments SET content = '', deleted_at = CURRENT_TIMESTAMP", rowsAffected: 1},
+		)
+
+		if err := (&CommentStore{db: db}).Delete(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+		if fake.ran("DELETE FROM comments") || !fake.ran("COMMIT") {
+			t.Fatalf("expected the comment to be kept as a tombstone, ran %q", fake.executed)
+		}
+	})
+
+	t.Run("should remove a tombstone once its last reply is gone", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			lockComment(true),
+			hasReplies(false),
+			fakeStatement{match: "DELETE FROM comments WHERE id = $1", rowsAffected: 1},
+		)
+
+		if err := (&CommentStore{db: db}).Delete(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+	})
+
+	t.Run("should not delete a tombstone that still has replies again", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			lockComment(true),
+			hasReplies(true),
+		)
+
+		err := (&CommentStore{db: db}).Delete(context.Background(), 1)
+		if !errors.Is(err, errCustom.ErrResourceNotFound) {
+			t.Fatalf("expected %v, got %v", errCustom.ErrResourceNotFound, err)
+		}
+
+		fake.assertDone()
+		if !fake.ran("ROLLBACK") {
+			t.Fatalf("expected the transaction to be rolled back, ran %q", fake.executed)
+		}
+	})
+
+	t.Run("should not find a missing comment", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			fakeStatement{match: "FOR UPDATE OF c", columns: []string{"deleted"}},
+		)
+
+		err := (&CommentStore{db: db}).Delete(context.Background(), 1)
+		if !errors.Is(err, errCustom.ErrResourceNotFound) {
+			t.Fatalf("expected %v, got %v", errCustom.ErrResourceNotFound, err)
+		}
+
+		fake.assertDone()
+	})
+
+	t.Run("should lock the comment before checking for replies", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			lockComment(false),
+			hasReplies(false),
+			fakeStatement{match: "DELETE FROM comments WHERE id = $1", rowsAffected: 1},
+		)
+
+		if err := (&CommentStore{db: db}).Delete(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		// a reply inserted after the check would otherwise be removed by the cascading delete
+		if len(fake.executed) < 3 || fake.executed[0] != "BEGIN" ||
+			!strings.Contains(fake.executed[1], "FOR UPDATE OF c") ||
+			!strings.Contains(fake.executed[1], commentOfLivePost) {
+			t.Fatalf("expected the comment of a live post to be locked first, ran %q", fake.executed)
+		}
+	})
+
+}
+
+func TestCommentStore_HidesCommentsOfDeletedPosts(t *testing.T) {
+
+	t.Run("should not find a comment of a deleted post", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: commentOfLivePost})
+
+		_, err := (&CommentStore{db: db}).GetByID(context.Background(), 1)
+		if !errors.Is(err, errCustom.ErrResourceNotFound) {
+			t.Fatalf("expected %v, got %v", errCustom.ErrResourceNotFound, err)
+		}
+
+		fake.assertDone()
+	})
+
+	t.Run("should only list and update comments of live posts", func(t *testing.T) {
+		db, fake := newFakeDB(t,
+			fakeStatement{match: commentOfLivePost},
+			fakeStatement{match: commentOfLivePost},
+			fakeStatement{match: commentOfLivePost},
+			fakeStatement{match: commentOfLivePost},
+		)
+		comments := &CommentStore{db: db}
+		query := NewPaginatedQuery()
+
+		if _, err := comments.GetByPostID(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := comments.GetRootsByPostID(context.Background(), 1, query); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := comments.GetReplies(context.Background(), 1, query); err != nil {
+			t.Fatal(err)
+		}
+
+		err := comments.Update(context.Background(), &models.Comment{ID: 1, Content: "edited"})
+		if !errors.Is(err, errCustom.ErrResourceNotFound) {
+			t.Fatalf("expected %v, got %v", errCustom.ErrResourceNotFound, err)
+		}
+
+		fake.assertDone()
+	})
+
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid cursor parameter")
+
+// feedCursor marks a position in a feed ordered by (created_at, id). It is handed to clients as an
+// opaque base64 token so the ordering columns can change without breaking the public contract.
+type feedCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"id"`
+	// Backward is set on prev cursors: the page is read towards the start of the feed.
+	Backward bool `json:"b,omitempty"`
+}
+
+func (c feedCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFeedCursor(s string) (*feedCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor feedCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	if cursor.ID <= 0 || cursor.CreatedAt.IsZero() {
+		return nil, errInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// cursorFor builds the cursor pointing at the given post.
+func cursorFor(post *PostWithMetadata, backward bool) (string, error) {
+	createdAt, err := time.Parse(time.RFC3339Nano, post.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+
+	return feedCursor{CreatedAt: createdAt, ID: post.ID, Backward: backward}.encode(), nil
+}
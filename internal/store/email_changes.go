@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// CreateEmailChange stores the new email and the hashed confirmation token for the user. Requesting another change
+// replaces the pending one, so only the most recent link works. It returns ErrConflict if an account already uses
+// the new email.
+func (u *UserStore) CreateEmailChange(ctx context.Context, userID int64, newEmail, token string, expiry time.Duration) error {
+
+	query := `
+	INSERT INTO email_changes (user_id, new_email, token, expires_at)
+	SELECT $1, $2, $3, $4
+	WHERE NOT EXISTS (SELECT 1 FROM users WHERE email = $2)
+	ON CONFLICT (user_id) DO UPDATE
+	SET new_email = EXCLUDED.new_email, token = EXCLUDED.token, expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+
+	err := execAffectingOne(ctx, u.db, query, userID, newEmail, token, time.Now().Add(expiry))
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return errCustom.ErrConflict
+	}
+
+	return err
+}
+
+// CancelEmailChange deletes the pending email change of the user, so its confirmation link stops working.
+// It returns ErrResourceNotFound if the user has no pending change.
+func (u *UserStore) CancelEmailChange(ctx context.Context, userID int64) error {
+
+	query := `DELETE FROM email_changes WHERE user_id = $1`
+
+	return execAffectingOne(ctx, u.db, query, userID)
+}
+
+// ConfirmEmailChange consumes the hashed confirmation token and replaces the email of the user it belonged to.
+// The ID and new email are set on user. It returns ErrResourceNotFound if the token is unknown or has expired,
+// and ErrConflict if another account took the email in the meantime; the pending change is kept in that case.
+func (u *UserStore) ConfirmEmailChange(ctx context.Context, token string, user *models.User) error {
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, u.db, func(tx *sql.Tx) error {
+
+		query := `
+		DELETE FROM email_changes
+		WHERE token = $1 AND expires_at > NOW()
+		RETURNING user_id, new_email
+		`
+
+		if err := tx.QueryRowContext(ctx, query, token).Scan(&user.ID, &user.Email); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		query = `
+		UPDATE users
+		SET email = $1, updated_at = NOW()
+		WHERE id = $2 AND activated = true
+		RETURNING username, updated_at
+		`
+
+		err := tx.QueryRowContext(ctx, query, user.Email, user.ID).Scan(&user.Username, &user.UpdatedAt)
+
+		return errCustom.HandleStorageError(err)
+	})
+}
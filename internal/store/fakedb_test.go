@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeStatement is the answer to the next statement the store runs, provided it contains match.
+type fakeStatement struct {
+	match        string
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+	err          error
+}
+
+// fakeDB answers the statements of a store method from a script, in order, so the method can be tested without
+// a database. It records every statement it ran, including the end of each transaction, and the arguments of
+// every query.
+type fakeDB struct {
+	mu       sync.Mutex
+	t        *testing.T
+	script   []fakeStatement
+	executed []string
+	args     [][]driver.Value
+}
+
+// newFakeDB opens a database that runs the given script.
+func newFakeDB(t *testing.T, script ...fakeStatement) (*sql.DB, *fakeDB) {
+	t.Helper()
+
+	fake := &fakeDB{t: t, script: script}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	return db, fake
+}
+
+// assertDone fails the test when part of the script was not run.
+func (f *fakeDB) assertDone() {
+	f.t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.script) > 0 {
+		f.t.Fatalf("expected a statement matching %q, ran %q", f.script[0].match, f.executed)
+	}
+}
+
+// ran reports whether a statement containing match was run.
+func (f *fakeDB) ran(match string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, query := range f.executed {
+		if strings.Contains(query, match) {
+			return true
+		}
+	}
+	return false
+}
+
+// argsOf returns the arguments of the first statement containing match that was run.
+func (f *fakeDB) argsOf(match string) []driver.Value {
+	f.t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, query := range f.executed {
+		if strings.Contains(query, match) {
+			return f.args[i]
+		}
+	}
+
+	f.t.Fatalf("expected a statement matching %q, ran %q", match, f.executed)
+	return nil
+}
+
+func (f *fakeDB) next(query string, args []driver.NamedValue) (fakeStatement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+
+	f.executed = append(f.executed, query)
+	f.args = append(f.args, values)
+
+	if len(f.script) == 0 || !strings.Contains(query, f.script[0].match) {
+		return fakeStatement{}, fmt.Errorf("unexpected statement %q", query)
+	}
+
+	statement := f.script[0]
+	f.script = f.script[1:]
+	return statement, statement.err
+}
+
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.executed = append(f.executed, query)
+	f.args = append(f.args, nil)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fake databases are opened with sql.OpenDB")
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	statement, err := c.db.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(statement.rowsAffected), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	statement, err := c.db.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: statement.columns, rows: statement.rows}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error   { tx.db.record("COMMIT"); return nil }
+func (tx fakeTx) Rollback() error { tx.db.record("ROLLBACK"); return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// FeedQuery combines pagination and filters for every feed endpoint.
+type FeedQuery struct {
+	PaginatedFeedQuery
+	FilterFeedQuery
+
+	// ViewerID is the user the feed is rendered for, used to flag posts they reacted to. Zero means anonymous.
+	ViewerID int64 `json:"-"`
+}
+
+func NewFeedQuery() *FeedQuery {
+	return &FeedQuery{
+		PaginatedFeedQuery: *NewPaginatedFeedQuery(),
+		FilterFeedQuery:    *NewFilterFeedQuery(),
+	}
+}
+
+// Parse extracts both pagination and filter parameters from the query string.
+func (q *FeedQuery) Parse(r *http.Request) error {
+	if err := q.PaginatedFeedQuery.Parse(r); err != nil {
+		return err
+	}
+
+	return q.FilterFeedQuery.Parse(r)
+}
+
+// sqlArgs collects positional query arguments for queries assembled from optional clauses.
+type sqlArgs []any
+
+// add appends value and returns its placeholder.
+func (a *sqlArgs) add(value any) string {
+	*a = append(*a, value)
+	return fmt.Sprintf("$%d", len(*a))
+}
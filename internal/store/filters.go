@@ -1,60 +1,117 @@
 package store
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/d4rthvadr/dusky-go/internal/utils"
+	"github.com/lib/pq"
 )
 
 type FilterFeedQuery struct {
-	Tags   []string `json:"tags" validate:"max=4"`
-	Search string   `json:"search" validate:"max=100"`
-	Since  string   `json:"since"`
-	Until  string   `json:"until"`
+	Tags      []string `json:"tags" validate:"max=4,dive,required,max=100"`
+	Search    string   `json:"search" validate:"max=100"`
+	Since     string   `json:"since"`
+	Until     string   `json:"until"`
+	AuthorIDs []int64  `json:"author_ids" validate:"max=20,dive,gt=0"`
 }
 
 func NewFilterFeedQuery() *FilterFeedQuery {
 	return &FilterFeedQuery{
-		Tags:   []string{},
-		Search: "",
-		Since:  "",
-		Until:  "",
+		Tags:      []string{},
+		Search:    "",
+		Since:     "",
+		Until:     "",
+		AuthorIDs: []int64{},
 	}
 }
 
 // Parse extracts filter parameters from the query string and populates the FilterFeedQuery struct.
-func (f *FilterFeedQuery) Parse(r *http.Request) {
+// Tags and author IDs are comma separated; since and until must be RFC 3339 timestamps.
+func (f *FilterFeedQuery) Parse(r *http.Request) error {
 	qs := r.URL.Query()
 
-	tags := qs.Get("tags")
-
-	if tags != "" {
-		f.Tags = strings.Split(tags, ",")
+	if tags := qs.Get("tags"); tags != "" {
+		f.Tags = splitList(tags)
 	}
 
-	search := qs.Get("search")
+	f.Search = strings.TrimSpace(qs.Get("search"))
 
-	limit := qs.Get("limit")
-	if limit != "" {
-		f.Search = search
+	if since := qs.Get("since"); since != "" {
+		parsedSince, err := utils.ParseStrToTime(since)
+		if err != nil {
+			return fmt.Errorf("invalid since parameter")
+		}
+		f.Since = parsedSince
 	}
 
-	if search != "" {
-		f.Search = search
+	if until := qs.Get("until"); until != "" {
+		parsedUntil, err := utils.ParseStrToTime(until)
+		if err != nil {
+			return fmt.Errorf("invalid until parameter")
+		}
+		f.Until = parsedUntil
 	}
-	since := qs.Get("since")
-	if since != "" {
-		parsedSince, err := utils.ParseStrToTime(since)
-		if err == nil {
-			f.Since = parsedSince
+
+	// both values are normalised to UTC RFC 3339, so they compare correctly as strings
+	if f.Since != "" && f.Until != "" && f.Since > f.Until {
+		return fmt.Errorf("since must not be after until")
+	}
+
+	if authors := qs.Get("authors"); authors != "" {
+		f.AuthorIDs = f.AuthorIDs[:0]
+		for _, author := range splitList(authors) {
+			authorID, err := strconv.ParseInt(author, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid authors parameter")
+			}
+			f.AuthorIDs = append(f.AuthorIDs, authorID)
 		}
 	}
-	until := qs.Get("until")
-	if until != "" {
-		parsedUntil, err := utils.ParseStrToTime(until)
-		if err == nil {
-			f.Until = parsedUntil
+
+	return nil
+}
+
+// whereClause adds the active filters to args and returns them as SQL conditions on the posts table aliased as p.
+func (f *FilterFeedQuery) whereClause(args *sqlArgs) string {
+	var clauses []string
+
+	if f.Search != "" {
+		clauses = append(clauses, "p.search_vector @@ websearch_to_tsquery('english', "+args.add(f.Search)+")")
+	}
+
+	if len(f.Tags) > 0 {
+		clauses = append(clauses, "p.tags @> "+args.add(pq.Array(f.Tags)))
+	}
+
+	if f.Since != "" {
+		clauses = append(clauses, "p.created_at >= "+args.add(f.Since)+"::timestamp")
+	}
+
+	if f.Until != "" {
+		clauses = append(clauses, "p.created_at <= "+args.add(f.Until)+"::timestamp")
+	}
+
+	if len(f.AuthorIDs) > 0 {
+		clauses = append(clauses, "p.user_id = ANY("+args.add(pq.Array(f.AuthorIDs))+"::bigint[])")
+	}
+
+	if len(clauses) == 0 {
+		return ""
+	}
+
+	return "AND " + strings.Join(clauses, " AND ")
+}
+
+// splitList splits a comma separated query parameter, dropping blank entries.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
 }
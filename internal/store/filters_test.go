@@ -0,0 +1,55 @@
+package store
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFilterFeedQuery_ParsesAllFilters(t *testing.T) {
+	request := httptest.NewRequest("GET", "/v1/users/feed?tags=go,%20sql,&search=chi&since=2025-01-01T02:00:00%2B02:00&until=2025-02-01T00:00:00Z&authors=3,7", nil)
+
+	filters := NewFilterFeedQuery()
+	if err := filters.Parse(request); err != nil {
+		t.Fatalf("expected filters to parse, got %v", err)
+	}
+
+	if len(filters.Tags) != 2 || filters.Tags[0] != "go" || filters.Tags[1] != "sql" {
+		t.Fatalf("unexpected tags %v", filters.Tags)
+	}
+	if filters.Since != "2025-01-01T00:00:00Z" {
+		t.Fatalf("expected since to be normalised to UTC, got %s", filters.Since)
+	}
+	if len(filters.AuthorIDs) != 2 || filters.AuthorIDs[0] != 3 || filters.AuthorIDs[1] != 7 {
+		t.Fatalf("unexpected author IDs %v", filters.AuthorIDs)
+	}
+
+	args := sqlArgs{int64(1)}
+	clause := filters.whereClause(&args)
+	if len(args) != 6 {
+		t.Fatalf("expected one argument per filter after the user ID, got %d", len(args))
+	}
+	if !strings.HasPrefix(clause, "AND ") || !strings.Contains(clause, "$6::bigint[]") {
+		t.Fatalf("unexpected where clause %q", clause)
+	}
+}
+
+func TestFilterFeedQuery_RejectsInvalidInput(t *testing.T) {
+	for _, rawQuery := range []string{
+		"since=yesterday",
+		"since=2025-02-01T00:00:00Z&until=2025-01-01T00:00:00Z",
+		"authors=1,abc",
+	} {
+		request := httptest.NewRequest("GET", "/v1/users/feed?"+rawQuery, nil)
+		if err := NewFilterFeedQuery().Parse(request); err == nil {
+			t.Fatalf("expected %q to be rejected", rawQuery)
+		}
+	}
+}
+
+func TestFilterFeedQuery_NoFiltersAddsNothing(t *testing.T) {
+	args := sqlArgs{}
+	if clause := NewFilterFeedQuery().whereClause(&args); clause != "" || len(args) != 0 {
+		t.Fatalf("expected empty clause without filters, got %q with %d args", clause, len(args))
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type LoginAttemptStore struct {
+	db *sql.DB
+}
+
+// Begin counts the failed attempts for the email after since that came after its last successful login, and
+// stores attempt as pending in the same transaction. Attempts for one email are serialized by an advisory lock,
+// so every attempt counts the ones that began before it, including those still pending. Attempts rejected
+// because the account was locked, or that only asked for the second factor, are not counted.
+func (s *LoginAttemptStore) Begin(ctx context.Context, attempt *models.LoginAttempt, since time.Time) (*models.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var failures models.LoginFailures
+
+	err := WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('login_attempts:' || $1))`, attempt.Email); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		query := `
+		SELECT COUNT(*), MAX(created_at)
+		FROM login_attempts
+		WHERE email = $1 AND succeeded = false AND created_at > $2
+			AND failure_reason NOT IN ($3, $4, $5, $6)
+			AND created_at > COALESCE(
+				(SELECT MAX(created_at) FROM login_attempts WHERE email = $1 AND succeeded = true),
+				'-infinity'
+			)
+		`
+
+		var lastFailedAt sql.NullTime
+		err := tx.QueryRowContext(ctx, query, attempt.Email, since,
+			models.LoginFailureLockedOut, models.LoginSecondFactorRequired, models.LoginPasswordConfirmed,
+			models.LoginSecondFactorConfirmed).
+			Scan(&failures.Count, &lastFailedAt)
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+		failures.LastFailedAt = lastFailedAt.Time
+
+		// clock_timestamp() rather than NOW(), so the attempt is dated after the lock was acquired
+		query = `
+		INSERT INTO login_attempts (user_id, email, ip_address, succeeded, failure_reason, created_at)
+		VALUES ($1, $2, $3, false, $4, clock_timestamp())
+		RETURNING id, created_at
+		`
+
+		err = tx.QueryRowContext(ctx, query, attempt.UserID, attempt.Email, attempt.IPAddress, models.LoginAttemptPending).
+			Scan(&attempt.ID, &attempt.CreatedAt)
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+		attempt.FailureReason = models.LoginAttemptPending
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &failures, nil
+}
+
+// Record stores the outcome of an attempt started with Begin. A successful attempt also updates the
+// last_login_at of the user.
+func (s *LoginAttemptStore) Record(ctx context.Context, attempt *models.LoginAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+		UPDATE login_attempts
+		SET user_id = $2, succeeded = $3, failure_reason = NULLIF($4, '')
+		WHERE id = $1
+		`
+
+		err := execAffectingOne(ctx, tx, query, attempt.ID, attempt.UserID, attempt.Succeeded, attempt.FailureReason)
+		if err != nil {
+			return err
+		}
+
+		if !attempt.Succeeded || attempt.UserID == nil {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET last_login_at = NOW() WHERE id = $1`, *attempt.UserID)
+		return errCustom.HandleStorageError(err)
+	})
+}
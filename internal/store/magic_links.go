@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+)
+
+// CreateMagicLink stores the hashed login token for the user. Requesting a new link replaces the pending one,
+// so only the most recent link works.
+func (u *UserStore) CreateMagicLink(ctx context.Context, userID int64, token string, expiry time.Duration) error {
+
+	query := `
+	INSERT INTO magic_links (user_id, token, expires_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE
+	SET token = EXCLUDED.token, expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	_, err := u.db.ExecContext(ctx, query, userID, token, time.Now().Add(expiry))
+
+	return errCustom.HandleStorageError(err)
+}
+
+// GetMagicLinkUserID returns the ID of the user the hashed login token belongs to without consuming it, so the
+// login can be refused before the link is used up. It returns ErrResourceNotFound if the token is unknown or
+// has expired.
+func (u *UserStore) GetMagicLinkUserID(ctx context.Context, token string) (int64, error) {
+
+	query := `
+	SELECT user_id FROM magic_links
+	WHERE token = $1 AND expires_at > NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var userID int64
+	if err := u.db.QueryRowContext(ctx, query, token).Scan(&userID); err != nil {
+		return 0, errCustom.HandleStorageError(err)
+	}
+
+	return userID, nil
+}
+
+// ConsumeMagicLink deletes the hashed login token and returns the ID of the user it belonged to.
+// It returns ErrResourceNotFound if the token is unknown or has expired.
+func (u *UserStore) ConsumeMagicLink(ctx context.Context, token string) (int64, error) {
+
+	query := `
+	DELETE FROM magic_links
+	WHERE token = $1 AND expires_at > NOW()
+	RETURNING user_id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var userID int64
+	if err := u.db.QueryRowContext(ctx, query, token).Scan(&userID); err != nil {
+		return 0, errCustom.HandleStorageError(err)
+	}
+
+	return userID, nil
+}
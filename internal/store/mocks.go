@@ -5,16 +5,28 @@ import (
 	"database/sql"
 	"time"
 
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/models"
 	"github.com/stretchr/testify/mock"
 )
 
 func NewMockStore() Storage {
 	return Storage{
-		Users: &UserStoreMock{},
+		Users:                &UserStoreMock{},
+		LoginAttempts:        &LoginAttemptStoreMock{},
+		TOTP:                 &TOTPStoreMock{},
+		PersonalAccessTokens: &PersonalAccessTokenStoreMock{},
+		Sessions:             &SessionStoreMock{},
+		Posts:                &PostStoreMock{},
+		Comments:             &CommentStoreMock{},
+		Permissions:          &PermissionStoreMock{},
+		Bookmarks:            &BookmarkStoreMock{},
 	}
 }
 
+// MockUserPassword is the password of the active user the mock store finds for any email or ID.
+const MockUserPassword = "correct-horse-battery-staple"
+
 type UserStoreMock struct {
 	mock.Mock
 }
@@ -22,8 +34,12 @@ type UserStoreMock struct {
 func (m *UserStoreMock) Create(context.Context, *sql.Tx, *models.User) error {
 	return nil
 }
-func (m *UserStoreMock) GetByID(context.Context, int64) (*models.User, error) {
-	return nil, nil
+func (m *UserStoreMock) GetByID(_ context.Context, userID int64) (*models.User, error) {
+	user := &models.User{ID: userID, Username: "mock", Email: "mock@example.com"}
+	if err := user.Password.Set(MockUserPassword); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 func (m *UserStoreMock) CreateAndInvite(context.Context, *models.User, string, time.Duration) error {
 	return nil
@@ -31,6 +47,222 @@ func (m *UserStoreMock) CreateAndInvite(context.Context, *models.User, string, t
 func (m *UserStoreMock) ActivateUser(context.Context, string) error {
 	return nil
 }
-func (m *UserStoreMock) GetByEmail(context.Context, string, *models.User) error {
+func (m *UserStoreMock) GetByEmail(_ context.Context, email string, user *models.User) error {
+	user.ID = 1
+	user.Username = "mock"
+	user.Email = email
+	return user.Password.Set(MockUserPassword)
+}
+func (m *UserStoreMock) CreatePasswordReset(context.Context, int64, string, time.Duration) error {
+	return nil
+}
+func (m *UserStoreMock) ResetPassword(context.Context, string, *models.User) error {
+	return nil
+}
+func (m *UserStoreMock) GetUnactivatedByEmail(context.Context, string, *models.User) error {
+	return nil
+}
+func (m *UserStoreMock) ReplaceInvitation(context.Context, int64, string, time.Duration) error {
+	return nil
+}
+func (m *UserStoreMock) DeleteUnactivated(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *UserStoreMock) CreateMagicLink(context.Context, int64, string, time.Duration) error {
+	return nil
+}
+func (m *UserStoreMock) GetMagicLinkUserID(context.Context, string) (int64, error) {
+	return 0, nil
+}
+func (m *UserStoreMock) ConsumeMagicLink(context.Context, string) (int64, error) {
+	return 0, nil
+}
+func (m *UserStoreMock) UpdatePasswordHash(context.Context, int64, []byte, []byte) error {
+	return nil
+}
+func (m *UserStoreMock) UpdateProfile(context.Context, *models.User) error {
+	return nil
+}
+func (m *UserStoreMock) CreateEmailChange(context.Context, int64, string, string, time.Duration) error {
+	return nil
+}
+func (m *UserStoreMock) CancelEmailChange(context.Context, int64) error {
+	return nil
+}
+func (m *UserStoreMock) ConfirmEmailChange(context.Context, string, *models.User) error {
 	return nil
 }
+
+type LoginAttemptStoreMock struct {
+	mock.Mock
+}
+
+func (m *LoginAttemptStoreMock) Begin(context.Context, *models.LoginAttempt, time.Time) (*models.LoginFailures, error) {
+	return &models.LoginFailures{}, nil
+}
+func (m *LoginAttemptStoreMock) Record(context.Context, *models.LoginAttempt) error {
+	return nil
+}
+
+// TOTPStoreMock behaves as if no user has enrolled two-factor authentication.
+type TOTPStoreMock struct {
+	mock.Mock
+}
+
+func (m *TOTPStoreMock) Enroll(context.Context, int64, string) error {
+	return nil
+}
+func (m *TOTPStoreMock) GetByUserID(context.Context, int64) (*models.UserTOTP, error) {
+	return nil, errCustom.ErrResourceNotFound
+}
+func (m *TOTPStoreMock) Enable(context.Context, int64, int64, []string) error {
+	return nil
+}
+func (m *TOTPStoreMock) UseStep(context.Context, int64, int64) error {
+	return nil
+}
+func (m *TOTPStoreMock) UseRecoveryCode(context.Context, int64, string) error {
+	return nil
+}
+func (m *TOTPStoreMock) Disable(context.Context, int64) error {
+	return nil
+}
+
+// PersonalAccessTokenStoreMock accepts any token as a posts:read token of user 1.
+type PersonalAccessTokenStoreMock struct {
+	mock.Mock
+}
+
+func (m *PersonalAccessTokenStoreMock) Create(context.Context, *models.PersonalAccessToken) error {
+	return nil
+}
+func (m *PersonalAccessTokenStoreMock) GetByUserID(context.Context, int64) ([]models.PersonalAccessToken, error) {
+	return []models.PersonalAccessToken{}, nil
+}
+func (m *PersonalAccessTokenStoreMock) GetByHash(context.Context, string) (*models.PersonalAccessToken, error) {
+	return &models.PersonalAccessToken{ID: 1, UserID: 1, Name: "mock", Scopes: []string{models.ScopePostsRead}}, nil
+}
+func (m *PersonalAccessTokenStoreMock) Touch(context.Context, int64) error {
+	return nil
+}
+func (m *PersonalAccessTokenStoreMock) Delete(context.Context, int64, int64) error {
+	return nil
+}
+func (m *PersonalAccessTokenStoreMock) DeleteAllForUser(context.Context, int64) error {
+	return nil
+}
+
+type SessionStoreMock struct {
+	mock.Mock
+}
+
+func (m *SessionStoreMock) Create(context.Context, *models.Session, *models.RefreshToken) error {
+	return nil
+}
+func (m *SessionStoreMock) GetByUserID(context.Context, int64) ([]models.Session, error) {
+	return []models.Session{}, nil
+}
+func (m *SessionStoreMock) Touch(context.Context, string, int64) error {
+	return nil
+}
+func (m *SessionStoreMock) Revoke(context.Context, int64, string) error {
+	return nil
+}
+func (m *SessionStoreMock) RevokeAllExcept(context.Context, int64, string) error {
+	return nil
+}
+
+// PostStoreMock finds a live post of user 1 for any ID.
+type PostStoreMock struct {
+	mock.Mock
+}
+
+func (m *PostStoreMock) Create(context.Context, *models.Post) error {
+	return nil
+}
+func (m *PostStoreMock) GetByID(_ context.Context, postID int64) (*models.Post, error) {
+	return &models.Post{ID: postID, UserID: 1, Title: "mock", Content: "mock", Version: 1}, nil
+}
+func (m *PostStoreMock) Update(context.Context, *models.Post) error {
+	return nil
+}
+func (m *PostStoreMock) Delete(context.Context, int64, int) error {
+	return nil
+}
+func (m *PostStoreMock) GetDeletedByID(context.Context, int64) (*models.Post, error) {
+	return nil, errCustom.ErrResourceNotFound
+}
+func (m *PostStoreMock) Restore(context.Context, int64, time.Time) error {
+	return nil
+}
+func (m *PostStoreMock) Purge(context.Context, int64) error {
+	return nil
+}
+func (m *PostStoreMock) GetUserFeed(context.Context, int64, *FeedQuery) (*FeedPage, error) {
+	return &FeedPage{}, nil
+}
+func (m *PostStoreMock) GetUserPosts(context.Context, int64, *FeedQuery) (*FeedPage, error) {
+	return &FeedPage{}, nil
+}
+func (m *PostStoreMock) GetExploreFeed(context.Context, *FeedQuery) (*FeedPage, error) {
+	return &FeedPage{}, nil
+}
+
+// CommentStoreMock finds a root comment of user 1 on post 1 for any ID.
+type CommentStoreMock struct {
+	mock.Mock
+}
+
+func (m *CommentStoreMock) GetByPostID(context.Context, int64) ([]models.Comment, error) {
+	return []models.Comment{}, nil
+}
+func (m *CommentStoreMock) GetRootsByPostID(context.Context, int64, *PaginatedQuery) ([]models.Comment, error) {
+	return []models.Comment{}, nil
+}
+func (m *CommentStoreMock) GetReplies(context.Context, int64, *PaginatedQuery) ([]models.Comment, error) {
+	return []models.Comment{}, nil
+}
+func (m *CommentStoreMock) GetByID(_ context.Context, commentID int64) (*models.Comment, error) {
+	return &models.Comment{ID: commentID, PostID: 1, UserID: 1, Content: "mock"}, nil
+}
+func (m *CommentStoreMock) Create(context.Context, *models.Comment) error {
+	return nil
+}
+func (m *CommentStoreMock) Update(context.Context, *models.Comment) error {
+	return nil
+}
+func (m *CommentStoreMock) Delete(context.Context, int64) error {
+	return nil
+}
+
+// PermissionStoreMock grants no permission to any role.
+type PermissionStoreMock struct {
+	mock.Mock
+}
+
+func (m *PermissionStoreMock) List(context.Context) ([]models.Permission, error) {
+	return []models.Permission{}, nil
+}
+func (m *PermissionStoreMock) GetByRoleID(context.Context, int64) ([]models.Permission, error) {
+	return []models.Permission{}, nil
+}
+func (m *PermissionStoreMock) Grant(context.Context, int64, string) error {
+	return nil
+}
+func (m *PermissionStoreMock) Revoke(context.Context, int64, string) error {
+	return nil
+}
+
+type BookmarkStoreMock struct {
+	mock.Mock
+}
+
+func (m *BookmarkStoreMock) Add(context.Context, int64, int64) error {
+	return nil
+}
+func (m *BookmarkStoreMock) Remove(context.Context, int64, int64) error {
+	return nil
+}
+func (m *BookmarkStoreMock) GetUserBookmarks(context.Context, int64, *FeedQuery) (*FeedPage, error) {
+	return &FeedPage{}, nil
+}
@@ -6,14 +6,16 @@ import (
 	"strconv"
 )
 
+// PaginatedFeedQuery holds the pagination part of a feed request. Filters live in FilterFeedQuery and
+// both are combined by FeedQuery.
 type PaginatedFeedQuery struct {
 	Limit  int    `json:"limit" validate:"gte=1,lte=20"`
 	Offset int    `json:"offset" validate:"gte=0"`
 	Sort   string `json:"sort" validate:"oneof=asc desc"`
-	Search string `json:"search" validate:"omitempty"`
-	// additional filters can be added here, e.g. tags, date range, etc.
-	// should use a separate struct for filters and embed it here if there are many fields to avoid bloating this struct with too many fields that are not related to pagination
-	Tags []string `json:"tags" validate:"max=4"`
+	// Cursor is the opaque next_cursor/prev_cursor value from a previous page.
+	Cursor string `json:"cursor" validate:"omitempty"`
+
+	cursor *feedCursor
 }
 
 const PaginationQueryLimit = 20
@@ -23,8 +25,6 @@ func NewPaginatedFeedQuery() *PaginatedFeedQuery {
 		Limit:  PaginationQueryLimit,
 		Offset: 0,
 		Sort:   "desc",
-		Tags:   []string{},
-		Search: "",
 	}
 }
 
@@ -49,10 +49,157 @@ func (p *PaginatedFeedQuery) Parse(r *http.Request) error {
 		p.Offset = offset
 	}
 
-	p.Search = qs.Get("search")
-
 	if sort := qs.Get("sort"); sort != "" {
 		p.Sort = sort
 	}
+
+	if cursor := qs.Get("cursor"); cursor != "" {
+		if p.Offset > 0 {
+			return fmt.Errorf("cursor and offset parameters cannot be combined")
+		}
+
+		decoded, err := decodeFeedCursor(cursor)
+		if err != nil {
+			return err
+		}
+		p.Cursor = cursor
+		p.cursor = decoded
+	}
+	return nil
+}
+
+// UsesCursor reports whether the query is served in keyset (cursor) mode. Requests that page with
+// an offset keep the legacy LIMIT/OFFSET behaviour for backwards compatibility.
+func (p *PaginatedFeedQuery) UsesCursor() bool {
+	return p.cursor != nil || p.Offset == 0
+}
+
+// keysetOrder returns the row comparison operator and ORDER BY direction needed to read the page the
+// cursor points at, and whether the fetched rows must be reversed to restore the requested sort.
+func (p *PaginatedFeedQuery) keysetOrder() (op string, direction string, reversed bool) {
+	descending := p.Sort != "asc"
+	backward := p.cursor != nil && p.cursor.Backward
+
+	if descending != backward {
+		return "<", "DESC", backward
+	}
+	return ">", "ASC", backward
+}
+
+// keysetClause adds the cursor position to args and returns the condition that starts the page after it.
+// It is empty outside cursor mode and on the first page.
+func (p *PaginatedFeedQuery) keysetClause(args *sqlArgs) string {
+	if p.cursor == nil {
+		return ""
+	}
+
+	op, _, _ := p.keysetOrder()
+	return fmt.Sprintf("AND (p.created_at, p.id) %s (%s, %s)", op, args.add(p.cursor.CreatedAt), args.add(p.cursor.ID))
+}
+
+// limitClause adds the page size and offset to args. Cursor mode reads one extra row to detect a next page.
+func (p *PaginatedFeedQuery) limitClause(args *sqlArgs) string {
+	limit := p.Limit
+	if p.UsesCursor() {
+		limit++
+	}
+
+	return fmt.Sprintf("LIMIT %s OFFSET %s", args.add(limit), args.add(p.Offset))
+}
+
+// FeedPage is a page of feed posts. NextCursor and PrevCursor are only set in cursor mode,
+// and only when there is a page in that direction.
+type FeedPage struct {
+	Posts      []*PostWithMetadata
+	NextCursor string
+	PrevCursor string
+}
+
+// buildPage trims the look-ahead row fetched in cursor mode and works out the cursors for the neighbouring pages.
+func (p *PaginatedFeedQuery) buildPage(posts []*PostWithMetadata) (*FeedPage, error) {
+	if !p.UsesCursor() {
+		return &FeedPage{Posts: posts}, nil
+	}
+
+	hasMore := len(posts) > p.Limit
+	if hasMore {
+		posts = posts[:p.Limit]
+	}
+
+	_, _, reversed := p.keysetOrder()
+	if reversed {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+
+	page := &FeedPage{Posts: posts}
+	if len(posts) == 0 {
+		return page, nil
+	}
+
+	backward := p.cursor != nil && p.cursor.Backward
+
+	// Reading forward there is a previous page whenever we started from a cursor, and a next page
+	// only if the look-ahead row came back. Reading backward the roles are swapped.
+	hasNext := hasMore
+	hasPrev := p.cursor != nil
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	if hasNext {
+		next, err := cursorFor(posts[len(posts)-1], false)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+
+	if hasPrev {
+		prev, err := cursorFor(posts[0], true)
+		if err != nil {
+			return nil, err
+		}
+		page.PrevCursor = prev
+	}
+
+	return page, nil
+}
+
+// PaginatedQuery holds plain limit/offset pagination for lists that have no feed filters, such as comments.
+type PaginatedQuery struct {
+	Limit  int `json:"limit" validate:"gte=1,lte=50"`
+	Offset int `json:"offset" validate:"gte=0"`
+}
+
+func NewPaginatedQuery() *PaginatedQuery {
+	return &PaginatedQuery{
+		Limit:  PaginationQueryLimit,
+		Offset: 0,
+	}
+}
+
+// Parse extracts limit and offset from the query string and populates the PaginatedQuery struct.
+func (p *PaginatedQuery) Parse(r *http.Request) error {
+
+	qs := r.URL.Query()
+
+	if limitStr := qs.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return fmt.Errorf("invalid limit parameter")
+		}
+		p.Limit = limit
+	}
+
+	if offsetStr := qs.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil {
+			return fmt.Errorf("invalid offset parameter")
+		}
+		p.Offset = offset
+	}
+
 	return nil
 }
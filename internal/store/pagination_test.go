@@ -0,0 +1,118 @@
+package store
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+func feedPost(id int64, createdAt time.Time) *PostWithMetadata {
+	return &PostWithMetadata{Post: models.Post{ID: id, CreatedAt: createdAt.Format(time.RFC3339Nano)}}
+}
+
+func TestFeedCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	encoded := feedCursor{CreatedAt: createdAt, ID: 42, Backward: true}.encode()
+
+	decoded, err := decodeFeedCursor(encoded)
+	if err != nil {
+		t.Fatalf("expected cursor to decode, got %v", err)
+	}
+	if decoded.ID != 42 || !decoded.CreatedAt.Equal(createdAt) || !decoded.Backward {
+		t.Fatalf("decoded cursor does not match, got %+v", decoded)
+	}
+}
+
+func TestFeedCursor_RejectsGarbage(t *testing.T) {
+	for _, value := range []string{"not-base64!", "bm90LWpzb24", "e30"} {
+		if _, err := decodeFeedCursor(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestPaginatedFeedQuery_RejectsCursorWithOffset(t *testing.T) {
+	cursor := feedCursor{CreatedAt: time.Now(), ID: 1}.encode()
+	request := httptest.NewRequest("GET", "/v1/users/feed?offset=20&cursor="+cursor, nil)
+
+	if err := NewPaginatedFeedQuery().Parse(request); err == nil {
+		t.Fatalf("expected cursor combined with offset to be rejected")
+	}
+}
+
+func TestPaginatedFeedQuery_BuildPageForward(t *testing.T) {
+	query := NewPaginatedFeedQuery()
+	query.Limit = 2
+
+	now := time.Now().UTC().Truncate(time.Second)
+	// one extra row is fetched to detect the next page
+	posts := []*PostWithMetadata{feedPost(3, now), feedPost(2, now.Add(-time.Minute)), feedPost(1, now.Add(-2*time.Minute))}
+
+	page, err := query.buildPage(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Posts) != 2 {
+		t.Fatalf("expected look-ahead row to be trimmed, got %d posts", len(page.Posts))
+	}
+	if page.NextCursor == "" {
+		t.Fatalf("expected a next cursor when more rows exist")
+	}
+	if page.PrevCursor != "" {
+		t.Fatalf("expected no prev cursor on the first page")
+	}
+
+	next, _ := decodeFeedCursor(page.NextCursor)
+	if next.ID != 2 || next.Backward {
+		t.Fatalf("expected next cursor to point forward at the last post, got %+v", next)
+	}
+}
+
+func TestPaginatedFeedQuery_BuildPageBackward(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	query := NewPaginatedFeedQuery()
+	query.Limit = 2
+	query.cursor = &feedCursor{CreatedAt: now.Add(-3 * time.Minute), ID: 1, Backward: true}
+
+	op, direction, reversed := query.keysetOrder()
+	if op != ">" || direction != "ASC" || !reversed {
+		t.Fatalf("unexpected keyset order for backward desc page: %s %s %v", op, direction, reversed)
+	}
+
+	// rows come back nearest-first when reading backward
+	posts := []*PostWithMetadata{feedPost(2, now.Add(-2*time.Minute)), feedPost(3, now.Add(-time.Minute))}
+
+	page, err := query.buildPage(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.Posts[0].ID != 3 || page.Posts[1].ID != 2 {
+		t.Fatalf("expected posts restored to descending order, got %d, %d", page.Posts[0].ID, page.Posts[1].ID)
+	}
+	if page.PrevCursor != "" {
+		t.Fatalf("expected no prev cursor when the start of the feed was reached")
+	}
+	if page.NextCursor == "" {
+		t.Fatalf("expected a next cursor back towards the page we came from")
+	}
+}
+
+func TestPaginatedFeedQuery_OffsetModeHasNoCursors(t *testing.T) {
+	query := NewPaginatedFeedQuery()
+	query.Limit = 1
+	query.Offset = 5
+
+	page, err := query.buildPage([]*PostWithMetadata{feedPost(1, time.Now())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.NextCursor != "" || page.PrevCursor != "" {
+		t.Fatalf("expected offset mode to leave cursors empty")
+	}
+}
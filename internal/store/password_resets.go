@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// CreatePasswordReset stores the hashed reset token for the user. Requesting a new reset replaces the pending one,
+// so only the most recent link works.
+func (u *UserStore) CreatePasswordReset(ctx context.Context, userID int64, token string, expiry time.Duration) error {
+
+	query := `
+	INSERT INTO password_resets (user_id, token, expires_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE
+	SET token = EXCLUDED.token, expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	_, err := u.db.ExecContext(ctx, query, userID, token, time.Now().Add(expiry))
+
+	return errCustom.HandleStorageError(err)
+}
+
+// ResetPassword consumes the hashed reset token and stores the new password hash of user. The ID of the user the
+// token belonged to is set on user. It returns ErrResourceNotFound if the token is unknown or has expired.
+func (u *UserStore) ResetPassword(ctx context.Context, token string, user *models.User) error {
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, u.db, func(tx *sql.Tx) error {
+
+		query := `
+		DELETE FROM password_resets
+		WHERE token = $1 AND expires_at > NOW()
+		RETURNING user_id
+		`
+
+		if err := tx.QueryRowContext(ctx, query, token).Scan(&user.ID); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		query = `
+		UPDATE users
+		SET password_hash = $1, updated_at = NOW()
+		WHERE id = $2 AND activated = true
+		`
+
+		result, err := tx.ExecContext(ctx, query, user.Password.Hash, user.ID)
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+		if rows == 0 {
+			return errCustom.ErrResourceNotFound
+		}
+
+		return nil
+	})
+}
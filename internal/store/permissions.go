@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type PermissionStore struct {
+	db *sql.DB
+}
+
+// List returns every known permission, ordered by name.
+func (s *PermissionStore) List(ctx context.Context) ([]models.Permission, error) {
+	query := `SELECT id, name, COALESCE(description, '') FROM permissions ORDER BY name`
+
+	return s.query(ctx, query)
+}
+
+// GetByRoleID returns the permissions granted to the role, ordered by name.
+func (s *PermissionStore) GetByRoleID(ctx context.Context, roleID int64) ([]models.Permission, error) {
+	query := `
+	SELECT p.id, p.name, COALESCE(p.description, '')
+	FROM permissions p
+	JOIN role_permissions rp ON rp.permission_id = p.id
+	WHERE rp.role_id = $1
+	ORDER BY p.name
+	`
+
+	return s.query(ctx, query, roleID)
+}
+
+// Grant gives the role the named permission. Granting a permission the role already has is a no-op;
+// an unknown permission is reported as ErrResourceNotFound.
+func (s *PermissionStore) Grant(ctx context.Context, roleID int64, name string) error {
+	query := `
+	WITH permission AS (
+		SELECT id FROM permissions WHERE name = $2
+	), granted AS (
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, id FROM permission
+		ON CONFLICT DO NOTHING
+	)
+	SELECT id FROM permission
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var permissionID int64
+	err := s.db.QueryRowContext(ctx, query, roleID, name).Scan(&permissionID)
+
+	return errCustom.HandleStorageError(err)
+}
+
+// Revoke takes the named permission away from the role. It reports ErrResourceNotFound when the role did not have it.
+func (s *PermissionStore) Revoke(ctx context.Context, roleID int64, name string) error {
+	query := `
+	DELETE FROM role_permissions rp
+	USING permissions p
+	WHERE rp.permission_id = p.id AND rp.role_id = $1 AND p.name = $2
+	`
+
+	return execAffectingOne(ctx, s.db, query, roleID, name)
+}
+
+func (s *PermissionStore) query(ctx context.Context, query string, args ...any) ([]models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+
+	for rows.Next() {
+		var permission models.Permission
+		if err := rows.Scan(&permission.ID, &permission.Name, &permission.Description); err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/lib/pq"
+)
+
+// lastUsedResolution limits how often last_used_at is written for a busy token.
+const lastUsedResolution = "1 minute"
+
+type PersonalAccessTokenStore struct {
+	db *sql.DB
+}
+
+// Create stores the token and sets its ID and creation time. A token with the same name for the user
+// is reported as ErrConflict.
+func (s *PersonalAccessTokenStore) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	query := `
+	INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, token.UserID, token.Name, token.TokenHash, pq.Array(token.Scopes), token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+
+	return errCustom.HandleStorageError(err)
+}
+
+// GetByUserID lists the tokens of the user, newest first.
+func (s *PersonalAccessTokenStore) GetByUserID(ctx context.Context, userID int64) ([]models.PersonalAccessToken, error) {
+	query := `
+	SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+	FROM personal_access_tokens
+	WHERE user_id = $1
+	ORDER BY created_at DESC, id DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+
+	for rows.Next() {
+		var token models.PersonalAccessToken
+		if err := scanPersonalAccessToken(rows, &token); err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return tokens, nil
+}
+
+// GetByHash returns the unexpired token with the given hash.
+func (s *PersonalAccessTokenStore) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	query := `
+	SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+	FROM personal_access_tokens
+	WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var token models.PersonalAccessToken
+	if err := scanPersonalAccessToken(s.db.QueryRowContext(ctx, query, tokenHash), &token); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return &token, nil
+}
+
+// Touch records that the token was just used. Writes within a minute of the previous one are skipped.
+func (s *PersonalAccessTokenStore) Touch(ctx context.Context, id int64) error {
+	query := `
+	UPDATE personal_access_tokens
+	SET last_used_at = NOW()
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '` + lastUsedResolution + `')
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id)
+	return errCustom.HandleStorageError(err)
+}
+
+// Delete revokes a token of the user. It returns ErrResourceNotFound if the user has no token with that ID.
+func (s *PersonalAccessTokenStore) Delete(ctx context.Context, userID, id int64) error {
+	query := `
+	DELETE FROM personal_access_tokens
+	WHERE id = $1 AND user_id = $2
+	`
+
+	return execAffectingOne(ctx, s.db, query, id, userID)
+}
+
+// DeleteAllForUser revokes every token of the user.
+func (s *PersonalAccessTokenStore) DeleteAllForUser(ctx context.Context, userID int64) error {
+	query := `DELETE FROM personal_access_tokens WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return errCustom.HandleStorageError(err)
+}
+
+func scanPersonalAccessToken(row rowScanner, token *models.PersonalAccessToken) error {
+	return row.Scan(&token.ID, &token.UserID, &token.Name, pq.Array(&token.Scopes), &token.ExpiresAt, &token.LastUsedAt, &token.CreatedAt)
+}
@@ -3,6 +3,9 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
 
 	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/models"
@@ -17,6 +20,9 @@ type PostWithMetadata struct {
 	models.Post
 	Username     string `json:"username"`
 	CommentCount int    `json:"comment_count"`
+	// ReactionCounts maps each reaction kind present on the post to its number of reactions.
+	ReactionCounts   map[string]int `json:"reaction_counts"`
+	ViewerHasReacted bool           `json:"viewer_has_reacted"`
 }
 
 // Create inserts a new post into the database and updates the post model with the generated ID and timestamps
@@ -34,19 +40,28 @@ func (p *PostStore) Create(ctx context.Context, post *models.Post) error {
 	return err
 }
 
-// GetByID retrieves a post by its ID, including its comments
+// GetByID retrieves a post by its ID. Soft-deleted posts are not returned.
 func (p *PostStore) GetByID(ctx context.Context, id int64) (*models.Post, error) {
+	return p.getByID(ctx, id, false)
+}
+
+// GetDeletedByID retrieves a soft-deleted post by its ID so it can be restored or purged.
+func (p *PostStore) GetDeletedByID(ctx context.Context, id int64) (*models.Post, error) {
+	return p.getByID(ctx, id, true)
+}
+
+func (p *PostStore) getByID(ctx context.Context, id int64, deleted bool) (*models.Post, error) {
 	query := `
 	SELECT id, title, content, version, user_id, tags, created_at, updated_at
 	FROM posts
-	WHERE id = $1
+	WHERE id = $1 AND (deleted_at IS NOT NULL) = $2
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
 	defer cancel()
 
 	var post models.Post
-	err := p.db.QueryRowContext(ctx, query, id).
+	err := p.db.QueryRowContext(ctx, query, id, deleted).
 		Scan(&post.ID, &post.Title, &post.Content, &post.Version, &post.UserID, pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt)
 
 	err = errCustom.HandleStorageError(err)
@@ -58,32 +73,97 @@ func (p *PostStore) GetByID(ctx context.Context, id int64) (*models.Post, error)
 	return &post, err
 }
 
+// Update saves the post's title, content and tags if it is still at post.Version, archiving the previous
+// version into post_revisions in the same transaction. A stale version fails with ErrEditConflict; a zero
+// version updates whatever version is current.
 func (p *PostStore) Update(ctx context.Context, post *models.Post) error {
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
 	defer cancel()
 
+	return WithTx(ctx, p.db, func(tx *sql.Tx) error {
+		if err := archivePostRevision(ctx, tx, post.ID, post.Version); err != nil {
+			return err
+		}
+
+		query := `
+		UPDATE posts
+		SET title = $1, content = $2, tags = $3, version = version + 1
+		WHERE id = $4 AND ($5 = 0 OR version = $5) AND deleted_at IS NULL
+		RETURNING updated_at, version
+		`
+		err := tx.QueryRowContext(ctx, query, post.Title, post.Content, pq.Array(post.Tags), post.ID, post.Version).
+			Scan(&post.UpdatedAt, &post.Version)
+		return errCustom.HandleStorageError(err)
+	})
+}
+
+// Delete soft-deletes a post. It stays in the database, hidden from reads, until it is restored or purged.
+// A non-zero version makes the delete conditional and fails with ErrEditConflict if the post has moved on.
+func (p *PostStore) Delete(ctx context.Context, id int64, version int) error {
 	query := `
 	UPDATE posts
-	SET title = $1, content = $2, tags = $3, version = version + 1
-	WHERE id = $4 and version = $5
-	RETURNING updated_at, version
+	SET deleted_at = NOW()
+	WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
 	`
-	err := p.db.QueryRowContext(ctx, query, post.Title, post.Content, pq.Array(post.Tags), post.ID, post.Version).
-		Scan(&post.UpdatedAt, &post.Version)
-	return errCustom.HandleStorageError(err)
+
+	err := execAffectingOne(ctx, p.db, query, id, version)
+	if errors.Is(err, errCustom.ErrResourceNotFound) && version != 0 {
+		return postMissError(ctx, p.db, id)
+	}
+
+	return err
 }
 
-// Delete removes a post from the database by its ID
-func (p *PostStore) Delete(ctx context.Context, id int64) error {
-	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
-	defer cancel()
+// Restore undoes the soft deletion of a post deleted at or after deletedSince.
+func (p *PostStore) Restore(ctx context.Context, id int64, deletedSince time.Time) error {
+	query := `
+	UPDATE posts
+	SET deleted_at = NULL
+	WHERE id = $1 AND deleted_at >= $2
+	`
 
+	return execAffectingOne(ctx, p.db, query, id, deletedSince)
+}
+
+// Purge permanently removes a soft-deleted post together with its comments and other dependent rows.
+func (p *PostStore) Purge(ctx context.Context, id int64) error {
 	query := `
 	DELETE FROM posts
-	WHERE id = $1
+	WHERE id = $1 AND deleted_at IS NOT NULL
 	`
 
-	result, err := p.db.ExecContext(ctx, query, id)
+	return execAffectingOne(ctx, p.db, query, id)
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// postMissError explains why a version-conditional write matched no post: ErrEditConflict if the post
+// still exists at another version, ErrResourceNotFound otherwise.
+func postMissError(ctx context.Context, q rowQuerier, id int64) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		return errCustom.HandleStorageError(err)
+	}
+
+	if exists {
+		return errCustom.ErrEditConflict
+	}
+	return errCustom.ErrResourceNotFound
+}
+
+type rowExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execAffectingOne runs a statement that targets a single row and reports ErrResourceNotFound when it matched nothing.
+func execAffectingOne(ctx context.Context, e rowExecer, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	result, err := e.ExecContext(ctx, query, args...)
 	if err != nil {
 		return errCustom.HandleStorageError(err)
 	}
@@ -100,49 +180,108 @@ func (p *PostStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (p *PostStore) GetUserFeed(ctx context.Context, userID int64, paginatedQuery *PaginatedFeedQuery) ([]*PostWithMetadata, error) {
+// feedScope narrows a feed to the posts it may show. join and where are SQL fragments that can refer to
+// the posts table as p; their arguments must already be in the sqlArgs handed to queryFeed.
+type feedScope struct {
+	join  string
+	where string
+}
+
+// GetUserFeed retrieves posts written by the user or by the users they follow, narrowed by the query filters.
+func (p *PostStore) GetUserFeed(ctx context.Context, userID int64, feedQuery *FeedQuery) (*FeedPage, error) {
+	args := sqlArgs{}
+	user := args.add(userID)
+
+	return queryFeed(ctx, p.db, feedScope{
+		join:  "left join user_followers f on f.follower_id = " + user + " and f.user_id = p.user_id",
+		where: "(f.user_id IS NOT NULL OR p.user_id = " + user + ")",
+	}, &args, feedQuery)
+}
+
+// GetUserPosts retrieves the timeline of posts written by a single user.
+func (p *PostStore) GetUserPosts(ctx context.Context, authorID int64, feedQuery *FeedQuery) (*FeedPage, error) {
+	args := sqlArgs{}
+
+	return queryFeed(ctx, p.db, feedScope{
+		where: "p.user_id = " + args.add(authorID),
+	}, &args, feedQuery)
+}
+
+// GetExploreFeed retrieves recent posts from everyone.
+func (p *PostStore) GetExploreFeed(ctx context.Context, feedQuery *FeedQuery) (*FeedPage, error) {
+	return queryFeed(ctx, p.db, feedScope{where: "TRUE"}, &sqlArgs{}, feedQuery)
+}
+
+// queryFeed runs a feed query for the given scope and is shared by every store that lists posts in the feed shape.
+// In cursor mode one extra row is fetched to tell whether a next page exists; offset mode is kept for clients
+// that still page by offset.
+func queryFeed(ctx context.Context, db *sql.DB, scope feedScope, args *sqlArgs, feedQuery *FeedQuery) (*FeedPage, error) {
+
+	viewerParam := args.add(feedQuery.ViewerID)
+	filterClause := feedQuery.whereClause(args)
+	keysetClause := feedQuery.keysetClause(args)
+	limitClause := feedQuery.limitClause(args)
+	_, direction, _ := feedQuery.keysetOrder()
 
 	query := `
 	SELECT 
 		p.id, p.title, p.content, p.user_id, p.tags, u.username, 
 		count(c.id) as comments_count, 
+		COALESCE((
+			SELECT jsonb_object_agg(rc.kind, rc.total)
+			FROM (SELECT kind, count(*) AS total FROM post_reactions WHERE post_id = p.id GROUP BY kind) rc
+		), '{}'::jsonb) AS reaction_counts,
+		EXISTS (SELECT 1 FROM post_reactions pr WHERE pr.post_id = p.id AND pr.user_id = ` + viewerParam + `) AS viewer_has_reacted,
 		p.created_at, p.updated_at
 	FROM posts p
 	left join comments c on p.id = c.post_id
 	left join users u on p.user_id = u.id
-	left join user_followers f on f.user_id = $1 and f.follower_id = p.user_id
-	WHERE (f.follower_id IS NOT NULL OR p.user_id = $1)
-	AND ($4 = '' OR p.title ILIKE '%' || $4 || '%' OR p.content ILIKE '%' || $4 || '%') 
-	AND (p.tags @> $5 OR $5 = '{}')
+	` + scope.join + `
+	WHERE ` + scope.where + ` AND p.deleted_at IS NULL
+	` + filterClause + `
+	` + keysetClause + `
 	GROUP BY p.id, u.username
-	ORDER BY p.created_at ` + paginatedQuery.Sort + `
-	LIMIT $2 OFFSET $3
+	ORDER BY p.created_at ` + direction + `, p.id ` + direction + `
+	` + limitClause + `
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
 	defer cancel()
 
-	rows, err := p.db.QueryContext(ctx, query, userID, paginatedQuery.Limit, paginatedQuery.Offset, paginatedQuery.Search, pq.Array(paginatedQuery.Tags))
+	rows, err := db.QueryContext(ctx, query, *args...)
 
 	if err != nil {
 		return nil, errCustom.HandleStorageError(err)
 	}
 	defer rows.Close()
 
-	var posts []*PostWithMetadata
+	posts := []*PostWithMetadata{}
 
 	for rows.Next() {
 		var post PostWithMetadata
-		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, pq.Array(&post.Tags), &post.User.Username, &post.CommentCount, &post.CreatedAt, &post.UpdatedAt)
+		var reactionCounts []byte
+		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, pq.Array(&post.Tags), &post.User.Username, &post.CommentCount,
+			&reactionCounts, &post.ViewerHasReacted, &post.CreatedAt, &post.UpdatedAt)
 		if err != nil {
 			return nil, errCustom.HandleStorageError(err)
 		}
+
+		if err := json.Unmarshal(reactionCounts, &post.ReactionCounts); err != nil {
+			return nil, err
+		}
+
 		posts = append(posts, &PostWithMetadata{
-			Post:         post.Post,
-			Username:     post.User.Username,
-			CommentCount: post.CommentCount,
+			Post:             post.Post,
+			Username:         post.User.Username,
+			CommentCount:     post.CommentCount,
+			ReactionCounts:   post.ReactionCounts,
+			ViewerHasReacted: post.ViewerHasReacted,
 		})
 	}
 
-	return posts, nil
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return feedQuery.buildPage(posts)
 }
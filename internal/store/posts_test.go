@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+)
+
+func TestPostStore_GetUserFeed(t *testing.T) {
+
+	t.Run("should show posts of the users the caller follows", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: "FROM posts p"})
+
+		if _, err := (&PostStore{db: db}).GetUserFeed(context.Background(), 7, NewFeedQuery()); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+
+		// the caller is the follower; the authors of the posts are the followed users
+		if !fake.ran("f.follower_id = $1 and f.user_id = p.user_id") {
+			t.Fatalf("expected the feed to join the users the caller follows, ran %q", fake.executed)
+		}
+		if args := fake.argsOf("FROM posts p"); len(args) == 0 || args[0] != int64(7) {
+			t.Fatalf("expected the caller to be the first argument, got %v", args)
+		}
+	})
+
+}
+
+func TestPostStore_Restore(t *testing.T) {
+
+	t.Run("should only restore a post deleted within the grace period", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: "WHERE id = $1 AND deleted_at >= $2"})
+		deletedSince := time.Now().Add(-time.Hour)
+
+		err := (&PostStore{db: db}).Restore(context.Background(), 1, deletedSince)
+		if !errors.Is(err, errCustom.ErrResourceNotFound) {
+			t.Fatalf("expected %v, got %v", errCustom.ErrResourceNotFound, err)
+		}
+
+		fake.assertDone()
+		if args := fake.argsOf("UPDATE posts"); len(args) != 2 || !args[1].(time.Time).Equal(deletedSince) {
+			t.Fatalf("expected the post and the start of the grace period as arguments, got %v", args)
+		}
+	})
+
+}
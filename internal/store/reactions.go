@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+type ReactionStore struct {
+	db *sql.DB
+}
+
+// Add records a reaction of the given kind by the user on a post. Reacting twice with the same kind is a no-op.
+func (s *ReactionStore) Add(ctx context.Context, postID, userID int64, kind models.ReactionKind) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	INSERT INTO post_reactions (post_id, user_id, kind)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (post_id, user_id, kind) DO NOTHING
+	`
+	_, err := s.db.ExecContext(ctx, query, postID, userID, kind)
+	return errCustom.HandleStorageError(err)
+}
+
+// Remove deletes the user's reaction of the given kind from a post. Removing a missing reaction is a no-op.
+func (s *ReactionStore) Remove(ctx context.Context, postID, userID int64, kind models.ReactionKind) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	DELETE FROM post_reactions
+	WHERE post_id = $1 AND user_id = $2 AND kind = $3
+	`
+	_, err := s.db.ExecContext(ctx, query, postID, userID, kind)
+	return errCustom.HandleStorageError(err)
+}
+
+// GetByPostID retrieves a page of the users who reacted to a post with the given kind, most recent first.
+func (s *ReactionStore) GetByPostID(ctx context.Context, postID int64, kind models.ReactionKind, paginatedQuery *PaginatedQuery) ([]models.Reaction, error) {
+	query := `
+	SELECT pr.post_id, pr.user_id, pr.kind, pr.created_at, u.username
+	FROM post_reactions pr
+	JOIN users u ON u.id = pr.user_id
+	WHERE pr.post_id = $1 AND pr.kind = $2
+	ORDER BY pr.created_at DESC, pr.user_id DESC
+	LIMIT $3 OFFSET $4
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, postID, kind, paginatedQuery.Limit, paginatedQuery.Offset)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	reactions := []models.Reaction{}
+
+	for rows.Next() {
+		var reaction models.Reaction
+		if err := rows.Scan(&reaction.PostID, &reaction.UserID, &reaction.Kind, &reaction.CreatedAt, &reaction.User.Username); err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		reaction.User.ID = reaction.UserID
+		reactions = append(reactions, reaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for refresh tokens that are unknown, expired or revoked.
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already used refresh token is presented again.
+	// Its family has been revoked by the time the error is returned.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+type RefreshTokenStore struct {
+	db *sql.DB
+}
+
+// createRefreshToken stores a new refresh token and sets its ID and creation time. The first token of a family
+// is created together with its session by SessionStore.Create.
+func createRefreshToken(ctx context.Context, q rowQuerier, token *models.RefreshToken) error {
+	query := `
+	INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at
+	`
+
+	err := q.QueryRowContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	return errCustom.HandleStorageError(err)
+}
+
+// Rotate marks the refresh token with the given hash as used and stores next in its place, in the same family
+// and for the same user. Presenting a token that was already used revokes its whole family.
+func (s *RefreshTokenStore) Rotate(ctx context.Context, tokenHash string, next *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var reusedFamilyID string
+
+	err := WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+		SELECT user_id, family_id, expires_at, used_at IS NOT NULL, revoked_at IS NOT NULL
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+		`
+
+		var current models.RefreshToken
+		var used, revoked bool
+		err := tx.QueryRowContext(ctx, query, tokenHash).
+			Scan(&current.UserID, &current.FamilyID, &current.ExpiresAt, &used, &revoked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		switch {
+		case revoked:
+			return ErrRefreshTokenInvalid
+		case used:
+			reusedFamilyID = current.FamilyID
+			return ErrRefreshTokenReused
+		case time.Now().After(current.ExpiresAt):
+			return ErrRefreshTokenInvalid
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1`, tokenHash); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+
+		return createRefreshToken(ctx, tx, next)
+	})
+
+	// the transaction has been rolled back, so the family is revoked on its own
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := s.RevokeFamily(ctx, reusedFamilyID); revokeErr != nil {
+			return revokeErr
+		}
+	}
+
+	return err
+}
+
+// RevokeFamily revokes every refresh token issued from the same login.
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	UPDATE refresh_tokens
+	SET revoked_at = NOW()
+	WHERE family_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := s.db.ExecContext(ctx, query, familyID)
+	return errCustom.HandleStorageError(err)
+}
+
+// RevokeByToken revokes the family of the user's refresh token with the given hash, ending that login.
+func (s *RefreshTokenStore) RevokeByToken(ctx context.Context, userID int64, tokenHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	UPDATE refresh_tokens
+	SET revoked_at = NOW()
+	WHERE revoked_at IS NULL AND family_id = (
+		SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2
+	)
+	`
+
+	_, err := s.db.ExecContext(ctx, query, tokenHash, userID)
+	return errCustom.HandleStorageError(err)
+}
+
+// RevokeAllForUser revokes every refresh token of the user.
+func (s *RefreshTokenStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	UPDATE refresh_tokens
+	SET revoked_at = NOW()
+	WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return errCustom.HandleStorageError(err)
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+	"github.com/lib/pq"
+)
+
+type RevisionStore struct {
+	db *sql.DB
+}
+
+const revisionColumns = `id, post_id, version, title, content, tags, created_at`
+
+func scanRevision(row rowScanner, revision *models.PostRevision) error {
+	return row.Scan(&revision.ID, &revision.PostID, &revision.Version, &revision.Title, &revision.Content,
+		pq.Array(&revision.Tags), &revision.CreatedAt)
+}
+
+// archivePostRevision copies the post as it is at the given version, or at its current version when version is
+// zero, into post_revisions. It locks the post row for the rest of the transaction and fails with ErrEditConflict
+// when the post is at another version.
+func archivePostRevision(ctx context.Context, tx *sql.Tx, postID int64, version int) error {
+	query := `
+	WITH current_post AS (
+		SELECT id, version, title, content, tags
+		FROM posts
+		WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NULL
+		FOR UPDATE
+	)
+	INSERT INTO post_revisions (post_id, version, title, content, tags)
+	SELECT id, version, title, content, tags FROM current_post
+	`
+
+	result, err := tx.ExecContext(ctx, query, postID, version)
+	if err != nil {
+		return errCustom.HandleStorageError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errCustom.HandleStorageError(err)
+	}
+
+	if rowsAffected == 0 {
+		return postMissError(ctx, tx, postID)
+	}
+
+	return nil
+}
+
+// GetByPostID retrieves a page of the archived revisions of a post, newest first.
+func (s *RevisionStore) GetByPostID(ctx context.Context, postID int64, paginatedQuery *PaginatedQuery) ([]models.PostRevision, error) {
+	query := `
+	SELECT ` + revisionColumns + `
+	FROM post_revisions
+	WHERE post_id = $1
+	ORDER BY version DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, postID, paginatedQuery.Limit, paginatedQuery.Offset)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	revisions := []models.PostRevision{}
+
+	for rows.Next() {
+		var revision models.PostRevision
+		if err := scanRevision(rows, &revision); err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return revisions, nil
+}
+
+// GetByVersion retrieves the archived revision of a post at the given version.
+func (s *RevisionStore) GetByVersion(ctx context.Context, postID int64, version int) (*models.PostRevision, error) {
+	query := `
+	SELECT ` + revisionColumns + `
+	FROM post_revisions
+	WHERE post_id = $1 AND version = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var revision models.PostRevision
+	if err := scanRevision(s.db.QueryRowContext(ctx, query, postID, version), &revision); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+)
+
+// RevokedTokenStore keeps access token revocations in Postgres for deployments without Redis.
+// Expired entries are ignored on reads and cleared out whenever a new revocation is written.
+type RevokedTokenStore struct {
+	db *sql.DB
+}
+
+// Revoke revokes a single access token until it expires.
+func (s *RevokedTokenStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		query := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+		`
+		_, err := tx.ExecContext(ctx, query, jti, expiresAt)
+		return errCustom.HandleStorageError(err)
+	})
+}
+
+// RevokeAllForUser revokes every access token of the user issued in a second before the one of revokedBefore.
+// The entry is kept until expiresAt, by which time every such token has expired.
+func (s *RevokedTokenStore) RevokeAllForUser(ctx context.Context, userID int64, revokedBefore, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+	INSERT INTO user_token_revocations (user_id, revoked_before, expires_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE
+	SET revoked_before = EXCLUDED.revoked_before, expires_at = EXCLUDED.expires_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query, userID, revokedBefore.Truncate(time.Second), expiresAt)
+	return errCustom.HandleStorageError(err)
+}
+
+// IsRevoked reports whether the token has been revoked on its own or by a revocation of all the user's tokens.
+func (s *RevokedTokenStore) IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	// iat has whole seconds, so only tokens issued in an earlier second count as issued before the revocation.
+	// Tokens issued earlier in its own second are refused anyway, as their session was revoked with it.
+	query := `
+	SELECT
+		EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())
+		OR EXISTS (
+			SELECT 1 FROM user_token_revocations
+			WHERE user_id = $2 AND expires_at > NOW() AND revoked_before > $3
+		)
+	`
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, query, jti, userID, issuedAt).Scan(&revoked)
+	if err != nil {
+		return false, errCustom.HandleStorageError(err)
+	}
+
+	return revoked, nil
+}
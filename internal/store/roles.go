@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
 	"github.com/d4rthvadr/dusky-go/internal/models"
 )
 
@@ -27,3 +28,49 @@ func (r *RoleStore) GetByName(ctx context.Context, name models.RoleStr) (*models
 
 	return &role, nil
 }
+
+// GetByID returns the role with the given ID, or ErrResourceNotFound.
+func (r *RoleStore) GetByID(ctx context.Context, id int64) (*models.Role, error) {
+	query := `SELECT id, name, COALESCE(description, ''), level FROM roles WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var role models.Role
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&role.ID, &role.Name, &role.Description, &role.Level)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return &role, nil
+}
+
+// List returns every role, lowest level first.
+func (r *RoleStore) List(ctx context.Context) ([]models.Role, error) {
+	query := `SELECT id, name, COALESCE(description, ''), level FROM roles ORDER BY level, id`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.Level); err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return roles, nil
+}
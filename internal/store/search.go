@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/lib/pq"
+)
+
+const (
+	SearchTypePosts    = "posts"
+	SearchTypeComments = "comments"
+
+	maxSearchTerms = 20
+
+	// matches are wrapped in <mark> tags; the rest of the snippet is returned as stored
+	headlineOptions = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	snippetOptions  = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+)
+
+type SearchStore struct {
+	db *sql.DB
+}
+
+// SearchQuery holds a full-text search request. Query is the raw user input; Parse compiles it into a tsquery.
+type SearchQuery struct {
+	PaginatedQuery
+	Query string `json:"q" validate:"required,max=200"`
+	Type  string `json:"type" validate:"oneof=posts comments"`
+
+	tsQuery string
+}
+
+type PostSearchResult struct {
+	ID        int64    `json:"id"`
+	UserID    int64    `json:"user_id"`
+	Username  string   `json:"username"`
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	Headline  string   `json:"headline"`
+	Snippet   string   `json:"snippet"`
+	Rank      float64  `json:"rank"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type CommentSearchResult struct {
+	ID        int64   `json:"id"`
+	PostID    int64   `json:"post_id"`
+	UserID    int64   `json:"user_id"`
+	Username  string  `json:"username"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+	CreatedAt string  `json:"created_at"`
+}
+
+func NewSearchQuery() *SearchQuery {
+	return &SearchQuery{
+		PaginatedQuery: *NewPaginatedQuery(),
+		Type:           SearchTypePosts,
+	}
+}
+
+// Parse extracts the search text, result type and pagination from the query string.
+func (s *SearchQuery) Parse(r *http.Request) error {
+	if err := s.PaginatedQuery.Parse(r); err != nil {
+		return err
+	}
+
+	qs := r.URL.Query()
+
+	if searchType := qs.Get("type"); searchType != "" {
+		s.Type = searchType
+	}
+
+	s.Query = strings.TrimSpace(qs.Get("q"))
+	if s.Query == "" {
+		return nil
+	}
+
+	tsQuery, err := parseSearchQuery(s.Query)
+	if err != nil {
+		return err
+	}
+	s.tsQuery = tsQuery
+
+	return nil
+}
+
+// parseSearchQuery turns user input into a to_tsquery expression. Words are ANDed together, "quoted phrases"
+// must match in order, a trailing * turns a word into a prefix match and a leading - excludes a word or phrase.
+// Only letters and digits reach the expression, so user input cannot inject tsquery operators.
+func parseSearchQuery(input string) (string, error) {
+	runes := []rune(input)
+	terms := []string{}
+	hasPositive := false
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negate := false
+		if runes[i] == '-' {
+			negate = true
+			i++
+		}
+
+		var words []string
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			words = searchLexemes(string(runes[i+1 : end]))
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			token := string(runes[i:end])
+			words = searchLexemes(token)
+			if len(words) > 0 && strings.HasSuffix(token, "*") {
+				words[len(words)-1] += ":*"
+			}
+			i = end
+		}
+
+		if len(words) == 0 {
+			continue
+		}
+
+		term := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+
+		if negate {
+			term = "!" + term
+		} else {
+			hasPositive = true
+		}
+
+		terms = append(terms, term)
+	}
+
+	if !hasPositive {
+		return "", errors.New("search query must contain at least one word to match")
+	}
+
+	if len(terms) > maxSearchTerms {
+		return "", fmt.Errorf("search query must not contain more than %d terms", maxSearchTerms)
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+// searchLexemes splits text into lower-cased runs of letters and digits.
+func searchLexemes(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Posts searches the titles, tags and content of posts that have not been deleted, best matches first.
+func (s *SearchStore) Posts(ctx context.Context, searchQuery *SearchQuery) ([]PostSearchResult, error) {
+	query := `
+	SELECT
+		p.id, p.user_id, u.username, p.title, p.tags,
+		ts_headline('english', p.title, q, '` + headlineOptions + `'),
+		ts_headline('english', p.content, q, '` + snippetOptions + `'),
+		ts_rank(p.search_vector, q) AS rank,
+		p.created_at
+	FROM posts p
+	JOIN users u ON u.id = p.user_id
+	CROSS JOIN to_tsquery('english', $1) AS q
+	WHERE p.search_vector @@ q AND p.deleted_at IS NULL
+	ORDER BY rank DESC, p.created_at DESC, p.id DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, searchQuery.tsQuery, searchQuery.Limit, searchQuery.Offset)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	results := []PostSearchResult{}
+
+	for rows.Next() {
+		var result PostSearchResult
+		err := rows.Scan(&result.ID, &result.UserID, &result.Username, &result.Title, pq.Array(&result.Tags),
+			&result.Headline, &result.Snippet, &result.Rank, &result.CreatedAt)
+		if err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return results, nil
+}
+
+// Comments searches the content of comments that have not been deleted, on posts that have not been deleted, best matches first.
+func (s *SearchStore) Comments(ctx context.Context, searchQuery *SearchQuery) ([]CommentSearchResult, error) {
+	query := `
+	SELECT
+		c.id, c.post_id, c.user_id, u.username,
+		ts_headline('english', c.content, q, '` + snippetOptions + `'),
+		ts_rank(c.search_vector, q) AS rank,
+		c.created_at
+	FROM comments c
+	JOIN users u ON u.id = c.user_id
+	JOIN posts p ON p.id = c.post_id AND p.deleted_at IS NULL
+	CROSS JOIN to_tsquery('english', $1) AS q
+	WHERE c.search_vector @@ q AND c.deleted_at IS NULL
+	ORDER BY rank DESC, c.created_at DESC, c.id DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, searchQuery.tsQuery, searchQuery.Limit, searchQuery.Offset)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	results := []CommentSearchResult{}
+
+	for rows.Next() {
+		var result CommentSearchResult
+		err := rows.Scan(&result.ID, &result.PostID, &result.UserID, &result.Username,
+			&result.Snippet, &result.Rank, &result.CreatedAt)
+		if err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return results, nil
+}
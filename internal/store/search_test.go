@@ -0,0 +1,50 @@
+package store
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	cases := map[string]string{
+		"golang":                     "golang",
+		"Go Routines":                "go & routines",
+		`"worker pool" channel`:      "(worker <-> pool) & channel",
+		"concur*":                    "concur:*",
+		"e-mail":                     "(e <-> mail)",
+		"chi -gin":                   "chi & !gin",
+		`-"hello world" docker`:      "!(hello <-> world) & docker",
+		"sql'); drop table posts --": "sql & drop & table & posts",
+	}
+
+	for input, expected := range cases {
+		actual, err := parseSearchQuery(input)
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %v", input, err)
+		}
+		if actual != expected {
+			t.Fatalf("parseSearchQuery(%q) = %q, expected %q", input, actual, expected)
+		}
+	}
+}
+
+func TestParseSearchQuery_RequiresAPositiveTerm(t *testing.T) {
+	for _, input := range []string{"-gin", "!!! ???", `""`} {
+		if _, err := parseSearchQuery(input); err == nil {
+			t.Fatalf("expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestSearchQuery_Parse(t *testing.T) {
+	request := httptest.NewRequest("GET", "/v1/search?q=chi+router&type=comments&limit=5", nil)
+
+	query := NewSearchQuery()
+	if err := query.Parse(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if query.Type != SearchTypeComments || query.Limit != 5 || query.tsQuery != "chi & router" {
+		t.Fatalf("unexpected parsed query %+v", query)
+	}
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// lastSeenResolution limits how often last_seen_at is written for a busy session.
+const lastSeenResolution = "5 minutes"
+
+// activeSessionCondition holds for sessions, aliased s, whose refresh token family can still be refreshed.
+const activeSessionCondition = `EXISTS (
+	SELECT 1 FROM refresh_tokens rt
+	WHERE rt.family_id = s.id AND rt.used_at IS NULL AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+)`
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+// Create stores the session together with the first refresh token of its family and sets their creation times.
+// The token's family ID is set to the session ID.
+func (s *SessionStore) Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+		INSERT INTO sessions (id, user_id, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, last_seen_at
+		`
+
+		err := tx.QueryRowContext(ctx, query, session.ID, session.UserID, session.UserAgent, session.IPAddress).
+			Scan(&session.CreatedAt, &session.LastSeenAt)
+		if err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		token.UserID = session.UserID
+		token.FamilyID = session.ID
+
+		return createRefreshToken(ctx, tx, token)
+	})
+}
+
+// GetByUserID lists the active sessions of the user, most recently seen first.
+func (s *SessionStore) GetByUserID(ctx context.Context, userID int64) ([]models.Session, error) {
+	query := `
+	SELECT s.id, s.user_id, s.user_agent, s.ip_address, s.created_at, s.last_seen_at
+	FROM sessions s
+	WHERE s.user_id = $1 AND ` + activeSessionCondition + `
+	ORDER BY s.last_seen_at DESC, s.created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt)
+		if err != nil {
+			return nil, errCustom.HandleStorageError(err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return sessions, nil
+}
+
+// Touch records that the user's session was just used. It returns ErrResourceNotFound when the session does not
+// exist or has ended, so tokens issued by it must no longer be accepted. Most calls only read: last_seen_at is
+// written once it is older than lastSeenResolution.
+func (s *SessionStore) Touch(ctx context.Context, id string, userID int64) error {
+	query := `
+	SELECT s.last_seen_at < NOW() - INTERVAL '` + lastSeenResolution + `'
+	FROM sessions s
+	WHERE s.id = $1 AND s.user_id = $2 AND ` + activeSessionCondition + `
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var stale bool
+	if err := s.db.QueryRowContext(ctx, query, id, userID).Scan(&stale); err != nil {
+		return errCustom.HandleStorageError(err)
+	}
+
+	if !stale {
+		return nil
+	}
+
+	// concurrent requests may both find the session stale; the condition lets only one of them write
+	query = `
+	UPDATE sessions SET last_seen_at = NOW()
+	WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '` + lastSeenResolution + `'
+	`
+
+	_, err := s.db.ExecContext(ctx, query, id)
+	return errCustom.HandleStorageError(err)
+}
+
+// Revoke ends the user's session by revoking every refresh token of its family. It returns ErrResourceNotFound
+// when the user has no such active session, so sessions that have already ended, expired or been revoked are
+// not found.
+func (s *SessionStore) Revoke(ctx context.Context, userID int64, id string) error {
+	query := `
+	UPDATE refresh_tokens
+	SET revoked_at = NOW()
+	WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+		AND EXISTS (SELECT 1 FROM sessions s WHERE s.id = $1 AND s.user_id = $2 AND ` + activeSessionCondition + `)
+	`
+
+	return execAffectingOne(ctx, s.db, query, id, userID)
+}
+
+// RevokeAllExcept ends every session of the user other than the one with the given ID.
+func (s *SessionStore) RevokeAllExcept(ctx context.Context, userID int64, id string) error {
+	query := `
+	UPDATE refresh_tokens
+	SET revoked_at = NOW()
+	WHERE user_id = $1 AND family_id::text <> $2 AND revoked_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userID, id)
+	return errCustom.HandleStorageError(err)
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+)
+
+func TestSessionStore_Revoke(t *testing.T) {
+
+	t.Run("should revoke every token of an active session", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: "UPDATE refresh_tokens", rowsAffected: 3})
+
+		if err := (&SessionStore{db: db}).Revoke(context.Background(), 1, "session"); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.assertDone()
+		if !fake.ran("WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL\n") {
+			t.Fatalf("expected the whole token family to be revoked, ran %q", fake.executed)
+		}
+	})
+
+	t.Run("should not find a session that is no longer active", func(t *testing.T) {
+		db, fake := newFakeDB(t, fakeStatement{match: activeSessionCondition})
+
+		err := (&SessionStore{db: db}).Revoke(context.Background(), 1, "session")
+		if !errors.Is(err, errCustom.ErrResourceNotFound) {
+			t.Fatalf("expected %v, got %v", errCustom.ErrResourceNotFound, err)
+		}
+
+		fake.assertDone()
+	})
+
+}
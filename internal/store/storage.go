@@ -15,12 +15,22 @@ type Storage struct {
 		Create(context.Context, *models.Post) error
 		GetByID(context.Context, int64) (*models.Post, error)
 		Update(context.Context, *models.Post) error
-		Delete(context.Context, int64) error
-		GetUserFeed(context.Context, int64, *PaginatedFeedQuery) ([]*PostWithMetadata, error)
+		Delete(context.Context, int64, int) error
+		GetDeletedByID(context.Context, int64) (*models.Post, error)
+		Restore(context.Context, int64, time.Time) error
+		Purge(context.Context, int64) error
+		GetUserFeed(context.Context, int64, *FeedQuery) (*FeedPage, error)
+		GetUserPosts(context.Context, int64, *FeedQuery) (*FeedPage, error)
+		GetExploreFeed(context.Context, *FeedQuery) (*FeedPage, error)
 	}
 	Comments interface {
 		GetByPostID(context.Context, int64) ([]models.Comment, error)
+		GetRootsByPostID(context.Context, int64, *PaginatedQuery) ([]models.Comment, error)
+		GetReplies(context.Context, int64, *PaginatedQuery) ([]models.Comment, error)
+		GetByID(context.Context, int64) (*models.Comment, error)
 		Create(context.Context, *models.Comment) error
+		Update(context.Context, *models.Comment) error
+		Delete(context.Context, int64) error
 	}
 	Users interface {
 		Create(context.Context, *sql.Tx, *models.User) error
@@ -28,6 +38,19 @@ type Storage struct {
 		CreateAndInvite(context.Context, *models.User, string, time.Duration) error
 		ActivateUser(context.Context, string) error
 		GetByEmail(context.Context, string, *models.User) error
+		CreatePasswordReset(context.Context, int64, string, time.Duration) error
+		ResetPassword(context.Context, string, *models.User) error
+		GetUnactivatedByEmail(context.Context, string, *models.User) error
+		ReplaceInvitation(context.Context, int64, string, time.Duration) error
+		DeleteUnactivated(context.Context, time.Time) (int64, error)
+		CreateMagicLink(context.Context, int64, string, time.Duration) error
+		GetMagicLinkUserID(context.Context, string) (int64, error)
+		ConsumeMagicLink(context.Context, string) (int64, error)
+		UpdatePasswordHash(context.Context, int64, []byte, []byte) error
+		UpdateProfile(context.Context, *models.User) error
+		CreateEmailChange(context.Context, int64, string, string, time.Duration) error
+		CancelEmailChange(context.Context, int64) error
+		ConfirmEmailChange(context.Context, string, *models.User) error
 	}
 	Followers interface {
 		Follow(context.Context, int64, int64) error
@@ -35,15 +58,90 @@ type Storage struct {
 	}
 	Roles interface {
 		GetByName(context.Context, models.RoleStr) (*models.Role, error)
+		GetByID(context.Context, int64) (*models.Role, error)
+		List(context.Context) ([]models.Role, error)
+	}
+	Permissions interface {
+		List(context.Context) ([]models.Permission, error)
+		GetByRoleID(context.Context, int64) ([]models.Permission, error)
+		Grant(context.Context, int64, string) error
+		Revoke(context.Context, int64, string) error
+	}
+	Reactions interface {
+		Add(context.Context, int64, int64, models.ReactionKind) error
+		Remove(context.Context, int64, int64, models.ReactionKind) error
+		GetByPostID(context.Context, int64, models.ReactionKind, *PaginatedQuery) ([]models.Reaction, error)
+	}
+	Bookmarks interface {
+		Add(context.Context, int64, int64) error
+		Remove(context.Context, int64, int64) error
+		GetUserBookmarks(context.Context, int64, *FeedQuery) (*FeedPage, error)
+	}
+	Revisions interface {
+		GetByPostID(context.Context, int64, *PaginatedQuery) ([]models.PostRevision, error)
+		GetByVersion(context.Context, int64, int) (*models.PostRevision, error)
+	}
+	RefreshTokens interface {
+		Rotate(context.Context, string, *models.RefreshToken) error
+		RevokeFamily(context.Context, string) error
+		RevokeByToken(context.Context, int64, string) error
+		RevokeAllForUser(context.Context, int64) error
+	}
+	Sessions interface {
+		Create(context.Context, *models.Session, *models.RefreshToken) error
+		GetByUserID(context.Context, int64) ([]models.Session, error)
+		Touch(context.Context, string, int64) error
+		Revoke(context.Context, int64, string) error
+		RevokeAllExcept(context.Context, int64, string) error
+	}
+	RevokedTokens interface {
+		Revoke(context.Context, string, time.Time) error
+		RevokeAllForUser(context.Context, int64, time.Time, time.Time) error
+		IsRevoked(context.Context, string, int64, time.Time) (bool, error)
+	}
+	LoginAttempts interface {
+		Begin(context.Context, *models.LoginAttempt, time.Time) (*models.LoginFailures, error)
+		Record(context.Context, *models.LoginAttempt) error
+	}
+	TOTP interface {
+		Enroll(context.Context, int64, string) error
+		GetByUserID(context.Context, int64) (*models.UserTOTP, error)
+		Enable(context.Context, int64, int64, []string) error
+		UseStep(context.Context, int64, int64) error
+		UseRecoveryCode(context.Context, int64, string) error
+		Disable(context.Context, int64) error
+	}
+	PersonalAccessTokens interface {
+		Create(context.Context, *models.PersonalAccessToken) error
+		GetByUserID(context.Context, int64) ([]models.PersonalAccessToken, error)
+		GetByHash(context.Context, string) (*models.PersonalAccessToken, error)
+		Touch(context.Context, int64) error
+		Delete(context.Context, int64, int64) error
+		DeleteAllForUser(context.Context, int64) error
+	}
+	Search interface {
+		Posts(context.Context, *SearchQuery) ([]PostSearchResult, error)
+		Comments(context.Context, *SearchQuery) ([]CommentSearchResult, error)
 	}
 }
 
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Posts:     &PostStore{db: db},
-		Comments:  &CommentStore{db: db},
-		Users:     &UserStore{db: db},
-		Followers: &FollowerStore{db: db},
-		Roles:     &RoleStore{db: db},
+		Posts:                &PostStore{db: db},
+		Comments:             &CommentStore{db: db},
+		Users:                &UserStore{db: db},
+		Followers:            &FollowerStore{db: db},
+		Roles:                &RoleStore{db: db},
+		Permissions:          &PermissionStore{db: db},
+		Reactions:            &ReactionStore{db: db},
+		Bookmarks:            &BookmarkStore{db: db},
+		Revisions:            &RevisionStore{db: db},
+		Search:               &SearchStore{db: db},
+		RefreshTokens:        &RefreshTokenStore{db: db},
+		RevokedTokens:        &RevokedTokenStore{db: db},
+		Sessions:             &SessionStore{db: db},
+		LoginAttempts:        &LoginAttemptStore{db: db},
+		TOTP:                 &TOTPStore{db: db},
+		PersonalAccessTokens: &PersonalAccessTokenStore{db: db},
 	}
 }
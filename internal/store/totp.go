@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	errCustom "github.com/d4rthvadr/dusky-go/internal/errors"
+	"github.com/d4rthvadr/dusky-go/internal/models"
+)
+
+// ErrTOTPCodeReused is returned when a TOTP code is presented for a time step that was already used.
+var ErrTOTPCodeReused = errors.New("two-factor code has already been used")
+
+type TOTPStore struct {
+	db *sql.DB
+}
+
+// Enroll stores a new, not yet enabled secret for the user, replacing a pending enrollment. It returns
+// ErrConflict when two-factor authentication is already enabled.
+func (s *TOTPStore) Enroll(ctx context.Context, userID int64, secret string) error {
+	query := `
+	INSERT INTO user_totp (user_id, secret)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE
+	SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = NOW()
+	WHERE user_totp.enabled_at IS NULL
+	`
+
+	err := execAffectingOne(ctx, s.db, query, userID, secret)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return errCustom.ErrConflict
+	}
+	return err
+}
+
+// GetByUserID returns the TOTP secret of the user, enabled or pending.
+func (s *TOTPStore) GetByUserID(ctx context.Context, userID int64) (*models.UserTOTP, error) {
+	query := `
+	SELECT user_id, secret, enabled_at, COALESCE(last_used_step, 0), created_at
+	FROM user_totp
+	WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	var totp models.UserTOTP
+	err := s.db.QueryRowContext(ctx, query, userID).
+		Scan(&totp.UserID, &totp.Secret, &totp.EnabledAt, &totp.LastUsedStep, &totp.CreatedAt)
+	if err != nil {
+		return nil, errCustom.HandleStorageError(err)
+	}
+
+	return &totp, nil
+}
+
+// Enable turns on two-factor authentication for a pending enrollment, marks step as used and replaces the
+// recovery codes of the user with the given hashes.
+func (s *TOTPStore) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+		UPDATE user_totp
+		SET enabled_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL
+		`
+		if err := execAffectingOne(ctx, tx, query, userID, step); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		for _, codeHash := range recoveryCodeHashes {
+			query := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+			if _, err := tx.ExecContext(ctx, query, userID, codeHash); err != nil {
+				return errCustom.HandleStorageError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// UseStep records that the code of step was used. Steps can only move forward, so a code cannot be replayed,
+// and ErrTOTPCodeReused is returned for a step at or before the last used one.
+func (s *TOTPStore) UseStep(ctx context.Context, userID int64, step int64) error {
+	query := `
+	UPDATE user_totp
+	SET last_used_step = $2
+	WHERE user_id = $1 AND enabled_at IS NOT NULL AND (last_used_step IS NULL OR last_used_step < $2)
+	`
+
+	err := execAffectingOne(ctx, s.db, query, userID, step)
+	if errors.Is(err, errCustom.ErrResourceNotFound) {
+		return ErrTOTPCodeReused
+	}
+	return err
+}
+
+// UseRecoveryCode marks the unused recovery code with the given hash as used. It returns ErrResourceNotFound
+// if the user has no such unused code.
+func (s *TOTPStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	query := `
+	UPDATE user_recovery_codes
+	SET used_at = NOW()
+	WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	return execAffectingOne(ctx, s.db, query, userID, codeHash)
+}
+
+// Disable removes the secret and recovery codes of the user.
+func (s *TOTPStore) Disable(ctx context.Context, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return errCustom.HandleStorageError(err)
+		}
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+		return errCustom.HandleStorageError(err)
+	})
+}
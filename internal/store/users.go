@@ -113,7 +113,8 @@ func (u *UserStore) Create(ctx context.Context, tx *sql.Tx, user *models.User) e
 func (u *UserStore) GetByID(ctx context.Context, id int64) (*models.User, error) {
 
 	query := `
-	SELECT users.id, users.username, users.email, users.password_hash, users.created_at, users.updated_at, roles.id, roles.name, roles.level
+	SELECT users.id, users.username, users.email, users.display_name, users.bio, users.avatar_url, users.password_hash,
+		users.created_at, users.updated_at, users.last_login_at, roles.id, roles.name, roles.level
 	FROM users join roles on users.role_id = roles.id
 	WHERE users.id = $1 AND users.activated = true
 	`
@@ -123,7 +124,8 @@ func (u *UserStore) GetByID(ctx context.Context, id int64) (*models.User, error)
 
 	var user models.User
 	err := u.db.QueryRowContext(ctx, query, id).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role.ID, &user.Role.Name, &user.Role.Level)
+		Scan(&user.ID, &user.Username, &user.Email, &user.DisplayName, &user.Bio, &user.AvatarURL, &user.Password,
+			&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt, &user.Role.ID, &user.Role.Name, &user.Role.Level)
 
 	if err != nil {
 		return nil, errCustom.HandleStorageError(err)
@@ -196,3 +198,95 @@ func (u *UserStore) GetByEmail(ctx context.Context, email string, user *models.U
 
 	return errCustom.HandleStorageError(err)
 }
+
+// GetUnactivatedByEmail loads the account with the given email if it has not been activated yet.
+func (u *UserStore) GetUnactivatedByEmail(ctx context.Context, email string, user *models.User) error {
+
+	query := `
+	SELECT id, username, email, created_at, updated_at
+	FROM users
+	WHERE email = $1 AND activated = false
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	err := u.db.QueryRowContext(ctx, query, email).
+		Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+
+	return errCustom.HandleStorageError(err)
+}
+
+// ReplaceInvitation drops the pending invitations of the user and creates a new one, so only the latest
+// activation link works.
+func (u *UserStore) ReplaceInvitation(ctx context.Context, userID int64, token string, invitationExpiry time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	return WithTx(ctx, u.db, func(tx *sql.Tx) error {
+
+		if err := u.deleteUserInvitation(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return u.createUserInvitation(ctx, tx, userID, token, invitationExpiry)
+	})
+}
+
+// DeleteUnactivated deletes accounts that were never activated and have no invitation that is still valid
+// after before. It returns the number of deleted accounts.
+func (u *UserStore) DeleteUnactivated(ctx context.Context, before time.Time) (int64, error) {
+
+	query := `
+	DELETE FROM users u
+	WHERE u.activated = false
+		AND u.created_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM user_invitations ui
+			WHERE ui.user_id = u.id AND ui.expires_at >= $1
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	result, err := u.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, errCustom.HandleStorageError(err)
+	}
+
+	return result.RowsAffected()
+}
+
+// UpdatePasswordHash replaces the user's password hash with a new hash of the same password, such as one made
+// with a stronger algorithm. It only applies while the stored hash is still oldHash, so a password changed in the
+// meantime is not overwritten, and reports ErrResourceNotFound otherwise.
+func (u *UserStore) UpdatePasswordHash(ctx context.Context, userID int64, oldHash, newHash []byte) error {
+	query := `
+	UPDATE users
+	SET password_hash = $1
+	WHERE id = $2 AND password_hash = $3
+	`
+
+	return execAffectingOne(ctx, u.db, query, newHash, userID, oldHash)
+}
+
+// UpdateProfile stores the username and public profile fields of the user and sets its update time.
+// A username that is already taken is reported as ErrConflict.
+func (u *UserStore) UpdateProfile(ctx context.Context, user *models.User) error {
+	query := `
+	UPDATE users
+	SET username = $1, display_name = $2, bio = $3, avatar_url = $4, updated_at = NOW()
+	WHERE id = $5 AND activated = true
+	RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, defaultQueryTimeoutDuration)
+	defer cancel()
+
+	err := u.db.QueryRowContext(ctx, query, user.Username, user.DisplayName, user.Bio, user.AvatarURL, user.ID).
+		Scan(&user.UpdatedAt)
+
+	return errCustom.HandleStorageError(err)
+}
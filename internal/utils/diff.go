@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxDiffCells bounds the lines removed times the lines added between two texts that DiffLines compares. The
+// table it builds grows with that product, so larger changes are refused instead of exhausting memory.
+const MaxDiffCells = 1 << 20
+
+// ErrDiffTooLarge is returned when the changed part of two texts is too large to diff.
+var ErrDiffTooLarge = errors.New("the texts differ too much to be diffed")
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine is one line of a line-based diff. Deleted lines come from the old text, inserted lines from the new one.
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines returns a line-based diff turning from into to, built from their longest common subsequence.
+// Unchanged leading and trailing lines are matched up front so typical edits stay cheap. It returns
+// ErrDiffTooLarge when the lines left in between exceed MaxDiffCells.
+func DiffLines(from, to string) ([]DiffLine, error) {
+	a, b := splitLines(from), splitLines(to)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	changedFrom, changedTo := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(changedFrom) > 0 && len(changedTo) > MaxDiffCells/len(changedFrom) {
+		return nil, ErrDiffTooLarge
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	diff = append(diff, diffLCS(changedFrom, changedTo)...)
+
+	for _, line := range a[len(a)-suffix:] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	return diff, nil
+}
+
+// diffLCS diffs two slices of lines with the classic dynamic programming table, where lcs[i][j] is the
+// length of the longest common subsequence of a[i:] and b[j:].
+func diffLCS(a, b []string) []DiffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	from := "title\nfirst line\nsecond line\nfooter"
+	to := "title\nfirst line changed\nsecond line\nnew line\nfooter"
+
+	expected := []DiffLine{
+		{Op: DiffEqual, Text: "title"},
+		{Op: DiffDelete, Text: "first line"},
+		{Op: DiffInsert, Text: "first line changed"},
+		{Op: DiffEqual, Text: "second line"},
+		{Op: DiffInsert, Text: "new line"},
+		{Op: DiffEqual, Text: "footer"},
+	}
+
+	diff, err := DiffLines(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != len(expected) {
+		t.Fatalf("expected %d diff lines, got %d: %v", len(expected), len(diff), diff)
+	}
+	for i := range expected {
+		if diff[i] != expected[i] {
+			t.Fatalf("line %d: expected %+v, got %+v", i, expected[i], diff[i])
+		}
+	}
+}
+
+func TestDiffLines_EmptySides(t *testing.T) {
+	if diff, _ := DiffLines("", ""); len(diff) != 0 {
+		t.Fatalf("expected no diff between empty texts, got %v", diff)
+	}
+
+	diff, _ := DiffLines("", "a\nb")
+	if len(diff) != 2 || diff[0].Op != DiffInsert || diff[1].Op != DiffInsert {
+		t.Fatalf("expected two inserts, got %v", diff)
+	}
+}
+
+func TestDiffLines_TooLarge(t *testing.T) {
+	from := strings.Repeat("old\n", 2000)
+	to := strings.Repeat("new\n", 1000)
+
+	if _, err := DiffLines(from, to); !errors.Is(err, ErrDiffTooLarge) {
+		t.Fatalf("expected ErrDiffTooLarge, got %v", err)
+	}
+
+	// only the changed lines count towards the limit
+	if _, err := DiffLines(from+"edited", from+"changed"); err != nil {
+		t.Fatalf("expected a long text with a small edit to diff, got %v", err)
+	}
+}
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_comments_updated_at ON comments;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,12 @@
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS updated_at timestamp(0) without time zone DEFAULT CURRENT_TIMESTAMP;
+
+-- Existing comments have never been edited, so they were last updated when created
+UPDATE comments
+SET updated_at = created_at;
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_comments_updated_at
+    BEFORE UPDATE ON comments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_comments_parent_id;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS depth;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,13 @@
+-- This migration turns comments into threads: replies point at their parent comment,
+-- depth is stored so nesting can be capped cheaply, and deleted_at lets a removed
+-- comment stay behind as a tombstone while it still has replies.
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE;
+
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) without time zone;
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_comments_search_vector;
+
+DROP INDEX IF EXISTS idx_posts_search_vector;
+
+DROP TRIGGER IF EXISTS update_comments_search_vector ON comments;
+
+DROP TRIGGER IF EXISTS update_posts_search_vector ON posts;
+
+DROP FUNCTION IF EXISTS comments_search_vector_update();
+
+DROP FUNCTION IF EXISTS posts_search_vector_update();
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS search_vector;
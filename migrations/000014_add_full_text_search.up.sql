@@ -0,0 +1,60 @@
+-- This migration adds full-text search over posts and comments. The search vectors are kept up to date by
+-- triggers so the application never has to build them, and are backfilled for existing rows.
+
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Titles weigh more than tags, and tags more than the body, when results are ranked
+CREATE OR REPLACE FUNCTION posts_search_vector_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(array_to_string(NEW.tags, ' '), '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.content, '')), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION comments_search_vector_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := to_tsvector('english', coalesce(NEW.content, ''));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_posts_search_vector
+    BEFORE INSERT OR UPDATE OF title, content, tags ON posts
+    FOR EACH ROW
+    EXECUTE FUNCTION posts_search_vector_update();
+
+CREATE TRIGGER update_comments_search_vector
+    BEFORE INSERT OR UPDATE OF content ON comments
+    FOR EACH ROW
+    EXECUTE FUNCTION comments_search_vector_update();
+
+-- Backfill existing rows. The updated_at triggers are paused so the backfill does not look like an edit.
+ALTER TABLE posts DISABLE TRIGGER update_posts_updated_at;
+
+UPDATE posts
+SET search_vector =
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(array_to_string(tags, ' '), '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(content, '')), 'C');
+
+ALTER TABLE posts ENABLE TRIGGER update_posts_updated_at;
+
+ALTER TABLE comments DISABLE TRIGGER update_comments_updated_at;
+
+UPDATE comments
+SET search_vector = to_tsvector('english', coalesce(content, ''));
+
+ALTER TABLE comments ENABLE TRIGGER update_comments_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING gin (search_vector);
+
+CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING gin (search_vector);
@@ -0,0 +1,10 @@
+-- Reaction kinds are validated by the application so new kinds can be added without a migration
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_kind ON post_reactions (post_id, kind, created_at);
@@ -0,0 +1,13 @@
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Deleting or restoring a post only changes deleted_at; that is not an edit, so updated_at is left alone.
+DROP TRIGGER IF EXISTS update_posts_updated_at ON posts;
+
+CREATE TRIGGER update_posts_updated_at
+    BEFORE UPDATE ON posts
+    FOR EACH ROW
+    WHEN (OLD.deleted_at IS NOT DISTINCT FROM NEW.deleted_at)
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,25 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ;
+
+-- Audit record of every login attempt. Recent failures per email drive the account lockout.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(64) NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    failure_reason VARCHAR(32),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email_created_at ON login_attempts (email, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts (user_id);
+
+-- Logging in only changes last_login_at; that is not a profile change, so updated_at is left alone.
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    WHEN (OLD.last_login_at IS NOT DISTINCT FROM NEW.last_login_at)
+    EXECUTE FUNCTION update_updated_at_column();
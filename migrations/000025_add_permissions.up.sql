@@ -0,0 +1,32 @@
+-- Named permissions granted to roles. Authorization checks ask for a permission instead of comparing role levels.
+CREATE TABLE IF NOT EXISTS permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+INSERT INTO permissions (name, description) VALUES
+('post.update.any', 'update posts and restore revisions of posts written by other users'),
+('post.delete.any', 'delete posts written by other users'),
+('post.purge', 'permanently delete soft-deleted posts'),
+('comment.update.any', 'update comments written by other users'),
+('comment.delete.any', 'delete comments written by other users'),
+('permission.manage', 'grant and revoke the permissions of roles')
+ON CONFLICT (name) DO NOTHING;
+
+-- editors keep what their role level allowed before: moderating content written by others
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'editor' AND p.name IN ('post.update.any', 'post.delete.any', 'comment.update.any', 'comment.delete.any')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+-- A session is one login: the refresh token family issued by it, and the device it was made from.
+-- The session ID is the family ID and is carried in the sid claim of its access tokens.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+-- logins made before sessions were tracked show up without device details
+INSERT INTO sessions (id, user_id, created_at, last_seen_at)
+SELECT family_id, MIN(user_id), MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+GROUP BY family_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE refresh_tokens
+ADD CONSTRAINT fk_refresh_tokens_session
+FOREIGN KEY (family_id) REFERENCES sessions(id)
+ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+CREATE TRIGGER update_posts_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- 000002 created the posts updated_at trigger a second time on users, so every update to a user row ran the
+-- trigger twice and ignored the WHEN clause of update_users_updated_at.
+DROP TRIGGER IF EXISTS update_posts_updated_at ON users;